@@ -1,6 +1,7 @@
 #!/usr/bin/env bash
 # Pre-edit analysis - shows impact analysis before file modifications
 # Auto-runs before significant edits to show which files might be affected
+# and which architecture rules the file already breaks
 
 set -euo pipefail
 
@@ -10,7 +11,22 @@ if [ -z "$FILE_PATH" ]; then
     exit 0
 fi
 
-DEP_GRAPH="$HOME/.claude/dep-graph.toon"
+# Architecture rules from .claude/dep-scanner.json. Existing violations are
+# shown rather than blocking, so the edit can fix them; quality-check.sh
+# blocks the ones still present afterwards.
+SCANNER="$HOME/.claude/bin/dependency-scanner"
+if [ -x "$SCANNER" ] && [ -f ".claude/dep-scanner.json" ]; then
+    CHECK_STATUS=0
+    VIOLATIONS=$("$SCANNER" check --files "$FILE_PATH" 2>/dev/null) || CHECK_STATUS=$?
+    if [ "$CHECK_STATUS" -eq 1 ]; then
+        echo ""
+        echo "Architecture rules broken by: $(basename "$FILE_PATH")"
+        echo "$VIOLATIONS" | sed '/./s/^/   /'
+        echo ""
+    fi
+fi
+
+DEP_GRAPH="${DEP_GRAPH_FILE:-.claude/dep-graph.toon}"
 
 if [ ! -f "$DEP_GRAPH" ]; then
     TOOL_RUNNER_PATH="$HOME/.claude/lib/tool-runner.sh"
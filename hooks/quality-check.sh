@@ -5,7 +5,9 @@
 # Runs AFTER Claude responds but BEFORE sending to user
 
 # This hook analyzes the response and provides improvement suggestions
-# It's a learning/reminder tool, not blocking
+# It's a learning/reminder tool; broken architecture rules from
+# .claude/dep-scanner.json only block (exit code 2) if the config sets
+# "blockOnViolations"
 
 echo ""
 echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
@@ -44,5 +46,30 @@ echo "⚠️  If any unchecked: Consider improvements for next time"
 echo "━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━"
 echo ""
 
-# Always continue (this is informational, not blocking)
+# Architecture rules: imports in the files changed since the last commit
+# that cross a forbidden boundary or add a new cycle are reported with file
+# and line. The scan reuses the parse cache, so only changed files are
+# reparsed, and responses that change nothing skip it.
+SCANNER="$HOME/.claude/bin/dependency-scanner"
+if [ -x "$SCANNER" ] && [ -f ".claude/dep-scanner.json" ] && git rev-parse --git-dir >/dev/null 2>&1; then
+    CHANGED=$({ git diff --name-only --relative HEAD 2>/dev/null; git ls-files --others --exclude-standard; } | sort -u | paste -sd, -)
+    if [ -n "$CHANGED" ]; then
+        CHECK_STATUS=0
+        VIOLATIONS=$("$SCANNER" check --hook --files "$CHANGED" 2>/dev/null) || CHECK_STATUS=$?
+        if [ "$CHECK_STATUS" -eq 1 ]; then
+            # "blockOnViolations" is set
+            echo "🚫 ARCHITECTURE RULES BROKEN" >&2
+            echo "$VIOLATIONS" >&2
+            echo "" >&2
+            echo "Fix these imports or update the rules in .claude/dep-scanner.json" >&2
+            exit 2
+        elif [ "$CHECK_STATUS" -eq 0 ] && echo "$VIOLATIONS" | grep -q "^Found"; then
+            echo "⚠️  Architecture rules broken by the changed files:"
+            echo "$VIOLATIONS" | sed '/./s/^/   /'
+            echo ""
+        fi
+    fi
+fi
+
+# Otherwise continue (the checklist is informational, not blocking)
 exit 0
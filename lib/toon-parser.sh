@@ -21,7 +21,20 @@ _resolve_path() {
     python3 -c "import os; print(os.path.normpath('$resolved'))" 2>/dev/null || echo "$resolved"
 }
 
-# Find file in graph (supports both absolute and relative paths)
+# Graph values are percent-escaped (%25 %2C %3A %3E %3B %0A %0D) so that
+# paths containing the "," ":" ">" ";" delimiters survive. Ordinary paths
+# are stored unchanged.
+_toon_escape() {
+    printf '%s\n' "$1" | sed -e 's/%/%25/g' -e 's/,/%2C/g' -e 's/:/%3A/g' -e 's/>/%3E/g' -e 's/;/%3B/g'
+}
+
+# Unescape graph values read from stdin, one per line
+_toon_unescape() {
+    sed -e 's/%2C/,/g' -e 's/%3A/:/g' -e 's/%3E/>/g' -e 's/%3B/;/g' -e 's/%0D/\r/g' -e 's/%0A/\n/g' -e 's/%25/%/g'
+}
+
+# Find file in graph (supports both absolute and relative paths).
+# Prints the path as stored in the graph, i.e. escaped.
 _find_file_in_graph() {
     local graph_file="$1"
     local target_file="$2"
@@ -31,14 +44,15 @@ _find_file_in_graph() {
     fi
 
     # Try as-is first (absolute path)
-    if grep -q "^FILE:$target_file$" "$graph_file" 2>/dev/null; then
+    target_file=$(_toon_escape "$target_file")
+    if grep -qxF "FILE:$target_file" "$graph_file" 2>/dev/null; then
         echo "$target_file"
         return 0
     fi
 
     # Try resolving as relative path
-    local abs_path=$(_resolve_path "$target_file")
-    if grep -q "^FILE:$abs_path$" "$graph_file" 2>/dev/null; then
+    local abs_path=$(_toon_escape "$(_resolve_path "$2")")
+    if grep -qxF "FILE:$abs_path" "$graph_file" 2>/dev/null; then
         echo "$abs_path"
         return 0
     fi
@@ -99,25 +113,36 @@ toon_get_imports() {
     toon_get_file_info "$graph_file" "$target_file" | grep "^IMPORTS:" | cut -d: -f2- | tr ',' '\n' | grep -v '^$' || true
 }
 
+# Imports are stored as path:line:kind[:symbols]; filter on the edge kind
+# (static, require, dynamic, re-export, side-effect, type-only)
+toon_get_imports_by_kind() {
+    local graph_file="$1"
+    local target_file="$2"
+    local kind="$3"
+
+    toon_get_imports "$graph_file" "$target_file" | awk -F: -v kind="$kind" '$3 == kind' || true
+}
+
 toon_get_exports() {
     local graph_file="$1"
     local target_file="$2"
 
-    toon_get_file_info "$graph_file" "$target_file" | grep "^EXPORTS:" | cut -d: -f2- | tr ',' '\n' | grep -v '^$' || true
+    # Package-level and private symbols are flagged; list the public ones
+    toon_get_file_info "$graph_file" "$target_file" | grep "^EXPORTS:" | cut -d: -f2- | tr ',' '\n' | grep -v '^$' | grep -Ev ':(package|private)$' || true
 }
 
 toon_get_importers() {
     local graph_file="$1"
     local target_file="$2"
 
-    toon_get_file_info "$graph_file" "$target_file" | grep "^IMPORTEDBY:" | cut -d: -f2- | tr ',' '\n' | grep -v '^$' || true
+    toon_get_file_info "$graph_file" "$target_file" | grep "^IMPORTEDBY:" | cut -d: -f2- | tr ',' '\n' | grep -v '^$' | _toon_unescape || true
 }
 
 toon_get_language() {
     local graph_file="$1"
     local target_file="$2"
 
-    toon_get_file_info "$graph_file" "$target_file" | grep "^LANG:" | cut -d: -f2-
+    toon_get_file_info "$graph_file" "$target_file" | grep "^LANG:" | cut -d: -f2- | _toon_unescape
 }
 
 toon_count_importers() {
@@ -141,7 +166,7 @@ toon_list_files() {
         return 1
     fi
 
-    grep "^FILE:" "$graph_file" | cut -d: -f2-
+    grep "^FILE:" "$graph_file" | cut -d: -f2- | _toon_unescape
 }
 
 toon_get_circular() {
@@ -151,7 +176,7 @@ toon_get_circular() {
         return 1
     fi
 
-    grep "^CIRCULAR:" "$graph_file" 2>/dev/null | cut -d: -f2- | sed 's/>/ -> /g' || true
+    grep "^CIRCULAR:" "$graph_file" 2>/dev/null | cut -d: -f2- | sed 's/>/ -> /g' | _toon_unescape || true
 }
 
 toon_get_deadcode() {
@@ -161,7 +186,7 @@ toon_get_deadcode() {
         return 1
     fi
 
-    grep "^DEADCODE:" "$graph_file" 2>/dev/null | cut -d: -f2- || true
+    grep "^DEADCODE:" "$graph_file" 2>/dev/null | cut -d: -f2- | _toon_unescape || true
 }
 
 toon_count_files() {
@@ -194,9 +219,12 @@ toon_search_files() {
 
 if [[ "${BASH_SOURCE[0]}" != "${0}" ]]; then
     export -f _resolve_path
+    export -f _toon_escape
+    export -f _toon_unescape
     export -f _find_file_in_graph
     export -f toon_get_file_info
     export -f toon_get_imports
+    export -f toon_get_imports_by_kind
     export -f toon_get_exports
     export -f toon_get_importers
     export -f toon_get_language
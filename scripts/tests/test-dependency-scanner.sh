@@ -190,10 +190,13 @@ EOF
 
 "$SCANNER_BIN" --path "$TEST_DIR" --output "$OUTPUT_FILE" >/dev/null 2>&1
 CIRCULAR=$(toon_get_circular "$OUTPUT_FILE" | wc -l | tr -d ' ')
-if [[ "$CIRCULAR" -ge 1 ]]; then
-    pass "Detects circular dependencies"
+CYCLES=$("$SCANNER_BIN" query -graph "$OUTPUT_FILE" cycles 2>&1 || true)
+if [[ "$CIRCULAR" -ge 1 ]] && \
+   echo "$CYCLES" | grep -A1 "src/circular-a.ts:1 imports $TEST_DIR/src/circular-b.ts" | grep -q "src/circular-b.ts:1 imports $TEST_DIR/src/circular-a.ts" && \
+   echo "$CYCLES" | grep -A1 "^Break it by removing:" | grep -q "src/circular-[ab].ts:1 import of"; then
+    pass "Reports cycles in import order with line numbers and imports to remove"
 else
-    fail "Circular dependency detection" "Failed to detect circular dependency"
+    fail "Circular dependency detection" "Got: $CYCLES"
 fi
 
 # Test 9: Verbose mode
@@ -216,6 +219,653 @@ else
     fail "Version flag" "Output: $VERSION_OUTPUT"
 fi
 
+# Test 11: Export names and visibility
+echo ""
+echo "Testing export extraction..."
+AUTH_FILE=$(toon_list_files "$OUTPUT_FILE" | grep "auth.ts" | head -1)
+AUTH_EXPORTS=$(toon_get_exports "$OUTPUT_FILE" "$AUTH_FILE")
+if echo "$AUTH_EXPORTS" | grep -q "^login:function:" && \
+   echo "$AUTH_EXPORTS" | grep -q "^logout:function:"; then
+    pass "Extracts exported symbol names and kinds"
+else
+    fail "Export extraction" "Got: $AUTH_EXPORTS"
+fi
+
+# Test 12: CommonJS require and re-export edges
+echo ""
+echo "Testing require() and re-export detection..."
+cat > "$TEST_DIR/src/legacy.js" << 'EOF'
+const { login } = require('./auth');
+module.exports = { login };
+EOF
+
+cat > "$TEST_DIR/src/index.ts" << 'EOF'
+export * from './user';
+EOF
+
+"$SCANNER_BIN" --path "$TEST_DIR" --output "$OUTPUT_FILE" >/dev/null 2>&1
+LEGACY_FILE=$(toon_list_files "$OUTPUT_FILE" | grep "legacy.js" | head -1)
+INDEX_FILE=$(toon_list_files "$OUTPUT_FILE" | grep "index.ts" | head -1)
+if [[ -n "$(toon_get_imports_by_kind "$OUTPUT_FILE" "$LEGACY_FILE" require)" ]] && \
+   [[ -n "$(toon_get_imports_by_kind "$OUTPUT_FILE" "$INDEX_FILE" re-export)" ]]; then
+    pass "Detects require() and re-export edges with their kind"
+else
+    fail "Edge kind detection" "Missing require or re-export edge"
+fi
+
+# Test 13: .gitignore rules and -explain-skip
+echo ""
+echo "Testing .gitignore handling..."
+mkdir -p "$TEST_DIR/src/generated" "$TEST_DIR/src/env"
+echo "export const schema = {};" > "$TEST_DIR/src/generated/schema.ts"
+echo "export const config = {};" > "$TEST_DIR/src/env/config.ts"
+echo "src/generated/" > "$TEST_DIR/.gitignore"
+
+"$SCANNER_BIN" --path "$TEST_DIR" --output "$OUTPUT_FILE" >/dev/null 2>&1
+EXPLAIN=$("$SCANNER_BIN" --path "$TEST_DIR" --explain-skip "$TEST_DIR/src/generated/schema.ts" 2>&1)
+if ! toon_list_files "$OUTPUT_FILE" | grep -q "schema.ts" && \
+   toon_list_files "$OUTPUT_FILE" | grep -q "env/config.ts" && \
+   echo "$EXPLAIN" | grep -q ".gitignore:1"; then
+    pass "Honors .gitignore and explains skipped paths"
+else
+    fail ".gitignore handling" "Got: $EXPLAIN"
+fi
+rm -rf "$TEST_DIR/src/generated" "$TEST_DIR/src/env" "$TEST_DIR/.gitignore"
+
+# Test 14: Language detection recorded in graph metadata
+echo ""
+echo "Testing language detection metadata..."
+"$SCANNER_BIN" --path "$TEST_DIR" --output "$OUTPUT_FILE" >/dev/null 2>&1
+LANGUAGES=$(toon_get_meta "$OUTPUT_FILE" languages)
+if echo "$LANGUAGES" | grep -q "typescript"; then
+    pass "Records scanned languages in graph metadata"
+else
+    fail "Language metadata" "Got: $LANGUAGES"
+fi
+
+# Test 15: Native query subcommands
+echo ""
+echo "Testing query subcommands..."
+IMPACT=$("$SCANNER_BIN" query -graph "$OUTPUT_FILE" impact src/auth.ts 2>&1 || true)
+DEPS_PATH=$("$SCANNER_BIN" query -graph "$OUTPUT_FILE" path app.ts auth.ts 2>&1 || true)
+if echo "$IMPACT" | grep -q "src/user.ts" && echo "$DEPS_PATH" | grep -q "app.ts:2 imports"; then
+    pass "Answers impact and path queries from the saved graph"
+else
+    fail "Query subcommands" "Got: $IMPACT / $DEPS_PATH"
+fi
+
+# Test 16: Paths containing TOON delimiters round-trip
+echo ""
+echo "Testing TOON escaping..."
+echo "export const odd = 1;" > "$TEST_DIR/src/odd,name.ts"
+echo "import { odd } from './odd,name';" > "$TEST_DIR/src/uses-odd.ts"
+"$SCANNER_BIN" --path "$TEST_DIR" --output "$OUTPUT_FILE" >/dev/null 2>&1
+ODD_IMPORTERS=$("$SCANNER_BIN" query -graph "$OUTPUT_FILE" importers "src/odd,name.ts" 2>&1 || true)
+if toon_list_files "$OUTPUT_FILE" | grep -q "src/odd,name.ts$" && echo "$ODD_IMPORTERS" | grep -q "uses-odd.ts"; then
+    pass "Escapes delimiters in paths and reads them back"
+else
+    fail "TOON escaping" "Got: $ODD_IMPORTERS"
+fi
+
+# Test 17: Reachability from entry points
+echo ""
+echo "Testing entry-point reachability..."
+echo '{ "name": "fixture", "bin": "./dist/app.js" }' > "$TEST_DIR/package.json"
+"$SCANNER_BIN" --path "$TEST_DIR" --output "$OUTPUT_FILE" >/dev/null 2>&1
+DEAD=$("$SCANNER_BIN" query -graph "$OUTPUT_FILE" dead 2>&1 || true)
+if grep -q "^ENTRY:package.json bin" "$OUTPUT_FILE" && \
+   ! echo "$DEAD" | grep -q "src/app.ts" && \
+   echo "$DEAD" | grep -q "circular-a.ts (imported only from its unreachable import cycle"; then
+    pass "Reports files and cycles no entry point reaches"
+else
+    fail "Entry-point reachability" "Got: $DEAD"
+fi
+
+# Test 18: Exports no other file uses
+echo ""
+echo "Testing unused export detection..."
+echo "export function neverCalled() {}" >> "$TEST_DIR/src/auth.ts"
+"$SCANNER_BIN" --path "$TEST_DIR" --output "$OUTPUT_FILE" >/dev/null 2>&1
+UNUSED=$("$SCANNER_BIN" query -graph "$OUTPUT_FILE" unused 2>&1 || true)
+if echo "$UNUSED" | grep -q "neverCalled (function" && ! echo "$UNUSED" | grep -q "login"; then
+    pass "Reports exports that no other file imports"
+else
+    fail "Unused export detection" "Got: $UNUSED"
+fi
+
+# Test 19: Mermaid diagram of a file's neighborhood
+echo ""
+echo "Testing Mermaid output..."
+DIAGRAM_FILE="$TEST_DIR/deps.mmd"
+"$SCANNER_BIN" --path "$TEST_DIR" --output "$DIAGRAM_FILE" --focus src/circular-a.ts --hops 1 >/dev/null 2>&1
+if grep -q "^flowchart LR" "$DIAGRAM_FILE" 2>/dev/null && \
+   grep -q "class .* cycle" "$DIAGRAM_FILE" && \
+   ! grep -q "isolated.ts" "$DIAGRAM_FILE"; then
+    pass "Draws a file's neighborhood with cycles highlighted"
+else
+    fail "Mermaid output" "Got: $(cat "$DIAGRAM_FILE" 2>/dev/null)"
+fi
+
+# Test 20: Self-contained HTML report
+echo ""
+echo "Testing HTML report..."
+REPORT_FILE="$TEST_DIR/report.html"
+"$SCANNER_BIN" --path "$TEST_DIR" --output "$REPORT_FILE" >/dev/null 2>&1
+if grep -q '"path":"src/circular-a.ts"' "$REPORT_FILE" 2>/dev/null && \
+   ! grep -Eq '(src|href)="(https?:)?//' "$REPORT_FILE"; then
+    pass "Writes an offline report with the graph embedded"
+else
+    fail "HTML report" "Missing graph data or loads remote assets"
+fi
+
+# Test 21: Architecture rules
+echo ""
+echo "Testing architecture rule check..."
+mkdir -p "$TEST_DIR/.claude" "$TEST_DIR/src/domain" "$TEST_DIR/src/infra"
+echo 'export const db = 1;' > "$TEST_DIR/src/infra/db.ts"
+printf 'import { db } from "../infra/db";\nexport const user = db;\n' > "$TEST_DIR/src/domain/user.ts"
+cat > "$TEST_DIR/.claude/dep-scanner.json" << 'EOF'
+{ "rules": [{ "from": "src/domain/**", "disallow": ["src/infra/**"] }] }
+EOF
+CHECK_STATUS=0
+CHECK_OUTPUT=$(cd "$TEST_DIR" && "$SCANNER_BIN" check 2>&1) || CHECK_STATUS=$?
+if [ "$CHECK_STATUS" -eq 1 ] && echo "$CHECK_OUTPUT" | grep -q "^src/domain/user.ts:1: imports src/infra/db.ts"; then
+    pass "Reports rule violations with file and line and exits 1"
+else
+    fail "Architecture check" "Exit $CHECK_STATUS: $CHECK_OUTPUT"
+fi
+HOOK_STATUS=0
+(cd "$TEST_DIR" && "$SCANNER_BIN" check --hook --files src/domain/user.ts >/dev/null 2>&1) || HOOK_STATUS=$?
+cat > "$TEST_DIR/.claude/dep-scanner.json" << 'EOF'
+{ "rules": [{ "from": "src/domain/**", "disallow": ["src/infra/**"] }], "blockOnViolations": true }
+EOF
+BLOCK_STATUS=0
+(cd "$TEST_DIR" && "$SCANNER_BIN" check --hook --files src/domain/user.ts >/dev/null 2>&1) || BLOCK_STATUS=$?
+if [ "$HOOK_STATUS" -eq 0 ] && [ "$BLOCK_STATUS" -eq 1 ]; then
+    pass "Hook checks only fail when blockOnViolations is set"
+else
+    fail "Hook check" "Exit $HOOK_STATUS without blockOnViolations, $BLOCK_STATUS with it"
+fi
+rm -f "$TEST_DIR/.claude/dep-scanner.json"
+
+# Test 22: Graphs saved by the first scanners (unversioned, path:line imports)
+echo ""
+echo "Testing baseline graph format..."
+LEGACY_GRAPH="$TEST_DIR/legacy-graph.toon"
+cat > "$LEGACY_GRAPH" << 'EOF'
+FILE:/project/src/a.ts
+LANG:typescript
+IMPORTS:/project/src/b.ts:1,react:2
+EXPORTS:main:function:3
+IMPORTEDBY:
+---
+FILE:/project/src/b.ts
+LANG:typescript
+IMPORTS:/project/src/a.ts:4
+EXPORTS:helper:function:1
+IMPORTEDBY:/project/src/a.ts
+---
+CIRCULAR:/project/src/a.ts>/project/src/b.ts
+---
+META:lastUpdated=2025-11-15T21:30:00Z
+EOF
+LEGACY_IMPORTS=$("$SCANNER_BIN" query -graph "$LEGACY_GRAPH" imports src/a.ts 2>&1 || true)
+LEGACY_CYCLES=$("$SCANNER_BIN" query -graph "$LEGACY_GRAPH" cycles 2>&1 || true)
+if echo "$LEGACY_IMPORTS" | grep -q "/project/src/b.ts (line 1, static)" && \
+   echo "$LEGACY_CYCLES" | grep -q "/project/src/b.ts:4 imports /project/src/a.ts"; then
+    pass "Reads graphs written by the baseline scanner"
+else
+    fail "Baseline graph format" "Got: $LEGACY_IMPORTS $LEGACY_CYCLES"
+fi
+
+# Test 23: Each Python submodule imported on one line is its own violation
+echo ""
+echo "Testing architecture check on multi-name Python imports..."
+mkdir -p "$TEST_DIR/.claude" "$TEST_DIR/pyapp" "$TEST_DIR/pylib"
+touch "$TEST_DIR/pyapp/__init__.py" "$TEST_DIR/pylib/__init__.py"
+echo 'A = 1' > "$TEST_DIR/pylib/a.py"
+echo 'B = 1' > "$TEST_DIR/pylib/b.py"
+printf 'from pylib import a, b\n\nprint(a.A, b.B)\n' > "$TEST_DIR/pyapp/main.py"
+cat > "$TEST_DIR/.claude/dep-scanner.json" << 'EOF'
+{ "rules": [{ "from": "pyapp", "disallow": ["pylib"] }] }
+EOF
+CHECK_OUTPUT=$(cd "$TEST_DIR" && "$SCANNER_BIN" check 2>&1 || true)
+if echo "$CHECK_OUTPUT" | grep -q "^pyapp/main.py:1: imports pylib/a.py" && \
+   echo "$CHECK_OUTPUT" | grep -q "^pyapp/main.py:1: imports pylib/b.py"; then
+    pass "Reports every module of a multi-name import"
+else
+    fail "Multi-name import check" "Got: $CHECK_OUTPUT"
+fi
+rm -f "$TEST_DIR/.claude/dep-scanner.json"
+
+# Test 24: Imported symbols per edge
+echo ""
+echo "Testing imported symbol recording..."
+SYMBOLS_DIR="$TEST_DIR/fixtures/symbols"
+mkdir -p "$SYMBOLS_DIR/web" "$SYMBOLS_DIR/gosrc/util" "$SYMBOLS_DIR/py/pkg"
+printf 'export default function main() {}\nexport const a = 1;\n' > "$SYMBOLS_DIR/web/lib.ts"
+cat > "$SYMBOLS_DIR/web/app.ts" << 'EOF'
+import Main, { default as Other, a as b } from './lib';
+import * as ns from './lib';
+EOF
+printf 'module example.com/symbols\n\ngo 1.21\n' > "$SYMBOLS_DIR/gosrc/go.mod"
+printf 'package util\n\nfunc Do() {}\n' > "$SYMBOLS_DIR/gosrc/util/util.go"
+cat > "$SYMBOLS_DIR/gosrc/main.go" << 'EOF'
+package main
+
+import (
+	u "example.com/symbols/util"
+	_ "example.com/symbols/util"
+)
+
+func main() { u.Do() }
+EOF
+touch "$SYMBOLS_DIR/py/pkg/__init__.py"
+echo 'def a(): pass' > "$SYMBOLS_DIR/py/pkg/m.py"
+echo 'from pkg.m import a as b' > "$SYMBOLS_DIR/py/main.py"
+SYMBOLS_GRAPH="$TEST_DIR/symbols.toon"
+"$SCANNER_BIN" --path "$SYMBOLS_DIR" --output "$SYMBOLS_GRAPH" >/dev/null 2>&1
+TS_SYMBOLS=$(toon_get_imports "$SYMBOLS_GRAPH" web/app.ts)
+GO_SYMBOLS=$(toon_get_imports "$SYMBOLS_GRAPH" gosrc/main.go)
+PY_SYMBOLS=$(toon_get_imports "$SYMBOLS_GRAPH" py/main.py)
+if echo "$TS_SYMBOLS" | grep -qF "web/lib.ts:1:static:default as Main;default as Other;a as b" && \
+   echo "$TS_SYMBOLS" | grep -qF "web/lib.ts:2:static:* as ns" && \
+   echo "$GO_SYMBOLS" | grep -qF "util/util.go:4:static:u:Do" && \
+   echo "$GO_SYMBOLS" | grep -qF "util/util.go:5:side-effect:_" && \
+   echo "$PY_SYMBOLS" | grep -qF "pkg/m.py:1:static:a as b"; then
+    pass "Records the symbols and aliases each import binds"
+else
+    fail "Imported symbols" "Got: $TS_SYMBOLS $GO_SYMBOLS $PY_SYMBOLS"
+fi
+
+# Test 25: tsconfig paths and baseUrl aliases
+echo ""
+echo "Testing tsconfig alias resolution..."
+ALIAS_DIR="$TEST_DIR/fixtures/tsconfig"
+mkdir -p "$ALIAS_DIR/src/util" "$ALIAS_DIR/src/ui" "$ALIAS_DIR/src/components"
+cat > "$ALIAS_DIR/tsconfig.base.json" << 'EOF'
+{
+  // Shared by every package
+  "compilerOptions": {
+    "baseUrl": "./src",
+    "paths": {
+      "@/*": ["*"],
+      "@/components/*": ["ui/*"], /* longer prefix wins */
+    },
+  },
+}
+EOF
+echo '{ "extends": "./tsconfig.base.json" }' > "$ALIAS_DIR/tsconfig.json"
+echo 'export const format = 1;' > "$ALIAS_DIR/src/util/format.ts"
+echo 'export const Button = 1;' > "$ALIAS_DIR/src/ui/button.ts"
+echo 'export const Button = 2;' > "$ALIAS_DIR/src/components/button.ts"
+echo 'export const theme = 1;' > "$ALIAS_DIR/src/ui/theme.ts"
+cat > "$ALIAS_DIR/src/app.ts" << 'EOF'
+import { format } from '@/util/format.js';
+import { Button } from '@/components/button';
+import { theme } from 'ui/theme';
+EOF
+# Scan only src/ so the tsconfig is found above the scan root
+ALIAS_GRAPH="$TEST_DIR/alias.toon"
+"$SCANNER_BIN" --path "$ALIAS_DIR/src" --output "$ALIAS_GRAPH" >/dev/null 2>&1
+ALIAS_IMPORTS=$(toon_get_imports "$ALIAS_GRAPH" src/app.ts)
+if echo "$ALIAS_IMPORTS" | grep -qF "$ALIAS_DIR/src/util/format.ts:1:" && \
+   echo "$ALIAS_IMPORTS" | grep -qF "$ALIAS_DIR/src/ui/button.ts:2:" && \
+   echo "$ALIAS_IMPORTS" | grep -qF "$ALIAS_DIR/src/ui/theme.ts:3:"; then
+    pass "Resolves paths and baseUrl aliases from an extended tsconfig"
+else
+    fail "tsconfig aliases" "Got: $ALIAS_IMPORTS"
+fi
+
+# Test 26: Workspace packages (npm/yarn "workspaces" and pnpm-workspace.yaml)
+echo ""
+echo "Testing workspace package resolution..."
+WORKSPACE_OK=true
+WORKSPACE_GOT=""
+for MANAGER in npm pnpm; do
+    MONO_DIR="$TEST_DIR/fixtures/$MANAGER-workspace"
+    mkdir -p "$MONO_DIR/packages/shared/src" "$MONO_DIR/packages/shared/dist" "$MONO_DIR/packages/app/src"
+    if [ "$MANAGER" = "npm" ]; then
+        echo '{ "name": "mono", "private": true, "workspaces": ["packages/*"] }' > "$MONO_DIR/package.json"
+    else
+        echo '{ "name": "mono", "private": true }' > "$MONO_DIR/package.json"
+        printf "packages:\n  - 'packages/*'\n" > "$MONO_DIR/pnpm-workspace.yaml"
+    fi
+    # "source" is tried before "import" even though it is listed last
+    cat > "$MONO_DIR/packages/shared/package.json" << 'EOF'
+{
+  "name": "@scope/shared",
+  "exports": {
+    ".": { "types": "./dist/index.d.ts", "import": "./dist/index.js", "source": "./src/index.ts" },
+    "./button": "./src/button.ts"
+  }
+}
+EOF
+    echo 'export const shared = 1;' > "$MONO_DIR/packages/shared/src/index.ts"
+    echo 'export const shared = 1;' > "$MONO_DIR/packages/shared/dist/index.js"
+    echo 'export const Button = 1;' > "$MONO_DIR/packages/shared/src/button.ts"
+    echo '{ "name": "@scope/app" }' > "$MONO_DIR/packages/app/package.json"
+    printf "import { shared } from '@scope/shared';\nimport { Button } from '@scope/shared/button';\n" > "$MONO_DIR/packages/app/src/main.ts"
+
+    MONO_GRAPH="$TEST_DIR/$MANAGER-workspace.toon"
+    "$SCANNER_BIN" --path "$MONO_DIR" --output "$MONO_GRAPH" >/dev/null 2>&1
+    MONO_IMPORTS=$(toon_get_imports "$MONO_GRAPH" app/src/main.ts)
+    MONO_PACKAGE=$(toon_get_file_info "$MONO_GRAPH" shared/src/index.ts | grep "^PKG:" || true)
+    if ! echo "$MONO_IMPORTS" | grep -qF "$MONO_DIR/packages/shared/src/index.ts:1:" || \
+       ! echo "$MONO_IMPORTS" | grep -qF "$MONO_DIR/packages/shared/src/button.ts:2:" || \
+       [ "$MONO_PACKAGE" != "PKG:@scope/shared" ]; then
+        WORKSPACE_OK=false
+        WORKSPACE_GOT="$WORKSPACE_GOT [$MANAGER] $MONO_IMPORTS $MONO_PACKAGE"
+    fi
+done
+if $WORKSPACE_OK; then
+    pass "Resolves workspace package imports to their source files"
+else
+    fail "Workspace resolution" "Got:$WORKSPACE_GOT"
+fi
+
+# Test 27: Go imports resolve to the package's buildable files
+echo ""
+echo "Testing Go package resolution..."
+GOPKG_DIR="$TEST_DIR/fixtures/gopkg"
+mkdir -p "$GOPKG_DIR/store"
+printf 'module example.com/shop\n\ngo 1.21\n' > "$GOPKG_DIR/go.mod"
+printf 'package store\n\nfunc Get() {}\n' > "$GOPKG_DIR/store/get.go"
+printf 'package store\n\nfunc Put() {}\n' > "$GOPKG_DIR/store/put.go"
+printf 'package store\n\nimport "testing"\n\nfunc TestGet(t *testing.T) {}\n' > "$GOPKG_DIR/store/get_test.go"
+printf '//go:build ignore\n\npackage main\n\nfunc main() {}\n' > "$GOPKG_DIR/store/gen.go"
+printf 'package main\n\nimport "example.com/shop/store"\n\nfunc main() { store.Get() }\n' > "$GOPKG_DIR/main.go"
+GOPKG_GRAPH="$TEST_DIR/gopkg.toon"
+"$SCANNER_BIN" --path "$GOPKG_DIR" --output "$GOPKG_GRAPH" >/dev/null 2>&1
+GOPKG_IMPORTS=$(toon_get_imports "$GOPKG_GRAPH" "$GOPKG_DIR/main.go" | cut -d: -f1 | sort | tr '\n' ' ')
+if [ "$GOPKG_IMPORTS" = "$GOPKG_DIR/store/get.go $GOPKG_DIR/store/put.go " ]; then
+    pass "Resolves a Go import to every file of the package except tests and ignored files"
+else
+    fail "Go package resolution" "Got: $GOPKG_IMPORTS"
+fi
+
+# Test 28: go.work, nested modules and local replaces
+echo ""
+echo "Testing multi-module Go resolution..."
+GOWORK_DIR="$TEST_DIR/fixtures/gowork"
+mkdir -p "$GOWORK_DIR/x" "$GOWORK_DIR/ext-a" "$GOWORK_DIR/ext-b" "$GOWORK_DIR/legacy/api"
+printf 'go 1.21\n\nuse (\n\t.\n\t./x\n)\n\nreplace example.com/legacy => ./legacy\n' > "$GOWORK_DIR/go.work"
+printf 'module example.com/root\n\ngo 1.21\n\nreplace example.com/ext => ./ext-a\n' > "$GOWORK_DIR/go.mod"
+# A module nested in a one-letter directory replaces ext with another copy
+printf 'module example.com/x\n\ngo 1.21\n\nreplace example.com/ext => ../ext-b\n' > "$GOWORK_DIR/x/go.mod"
+for EXT in ext-a ext-b; do
+    printf 'module example.com/ext\n\ngo 1.21\n' > "$GOWORK_DIR/$EXT/go.mod"
+    printf 'package ext\n\nfunc Run() {}\n' > "$GOWORK_DIR/$EXT/ext.go"
+done
+printf 'module example.com/legacy-fork\n\ngo 1.21\n' > "$GOWORK_DIR/legacy/go.mod"
+printf 'package api\n\nfunc Call() {}\n' > "$GOWORK_DIR/legacy/api/api.go"
+printf 'package main\n\nimport (\n\t"example.com/ext"\n\t"example.com/legacy/api"\n)\n\nfunc main() { ext.Run(); api.Call() }\n' > "$GOWORK_DIR/main.go"
+printf 'package main\n\nimport "example.com/ext"\n\nfunc main() { ext.Run() }\n' > "$GOWORK_DIR/x/main.go"
+GOWORK_GRAPH="$TEST_DIR/gowork.toon"
+(cd "$GOWORK_DIR" && "$SCANNER_BIN" --path . --output "$GOWORK_GRAPH" >/dev/null 2>&1)
+ROOT_IMPORTS=$(toon_get_imports "$GOWORK_GRAPH" main.go)
+NESTED_IMPORTS=$(toon_get_imports "$GOWORK_GRAPH" x/main.go)
+if echo "$ROOT_IMPORTS" | grep -q "^ext-a/ext.go:4:" && \
+   echo "$ROOT_IMPORTS" | grep -q "^legacy/api/api.go:5:" && \
+   echo "$NESTED_IMPORTS" | grep -q "^ext-b/ext.go:3:"; then
+    pass "Applies the replaces of the innermost module and of go.work"
+else
+    fail "Multi-module Go resolution" "Got: $ROOT_IMPORTS / $NESTED_IMPORTS"
+fi
+
+# Test 29: Python relative imports, declared source roots and namespace packages
+echo ""
+echo "Testing Python module resolution..."
+PY_DIR="$TEST_DIR/fixtures/python"
+mkdir -p "$PY_DIR/libs/core/lib/corepkg" "$PY_DIR/libs/core/lib/ns" "$PY_DIR/libs/extra/source/ns" "$PY_DIR/app/service/handlers"
+printf '[project]\nname = "core"\n\n[tool.setuptools.packages.find]\nwhere = ["lib"]\n' > "$PY_DIR/libs/core/pyproject.toml"
+printf '[options]\npackage_dir =\n    =source\n' > "$PY_DIR/libs/extra/setup.cfg"
+touch "$PY_DIR/libs/core/lib/corepkg/__init__.py" "$PY_DIR/app/__init__.py" "$PY_DIR/app/service/__init__.py" "$PY_DIR/app/service/handlers/__init__.py"
+echo 'class Model: pass' > "$PY_DIR/libs/core/lib/corepkg/models.py"
+# ns has no __init__.py: its portions live under both projects' roots
+echo 'A = 1' > "$PY_DIR/libs/core/lib/ns/alpha.py"
+echo 'B = 1' > "$PY_DIR/libs/extra/source/ns/beta.py"
+echo 'class User: pass' > "$PY_DIR/app/service/models.py"
+echo 'def helper(): pass' > "$PY_DIR/app/service/handlers/util.py"
+cat > "$PY_DIR/app/service/handlers/api.py" << 'EOF'
+from ..models import User
+from . import util
+from corepkg import models
+import ns.alpha
+import ns.beta
+EOF
+PY_GRAPH="$TEST_DIR/python.toon"
+"$SCANNER_BIN" --path "$PY_DIR" --output "$PY_GRAPH" >/dev/null 2>&1
+PY_IMPORTS=$(toon_get_imports "$PY_GRAPH" handlers/api.py)
+if echo "$PY_IMPORTS" | grep -qF "$PY_DIR/app/service/models.py:1:static:User" && \
+   echo "$PY_IMPORTS" | grep -qF "$PY_DIR/app/service/handlers/util.py:2:static:util" && \
+   echo "$PY_IMPORTS" | grep -qF "$PY_DIR/libs/core/lib/corepkg/models.py:3:static:models" && \
+   echo "$PY_IMPORTS" | grep -qF "$PY_DIR/libs/core/lib/ns/alpha.py:4:" && \
+   echo "$PY_IMPORTS" | grep -qF "$PY_DIR/libs/extra/source/ns/beta.py:5:"; then
+    pass "Resolves relative, submodule and namespace package imports across source roots"
+else
+    fail "Python module resolution" "Got: $PY_IMPORTS"
+fi
+
+# Test 30: Parallel parsing gives the same graph as a single worker
+echo ""
+echo "Testing parallel scan determinism..."
+"$SCANNER_BIN" --path "$TEST_DIR" --output "$TEST_DIR/jobs1.toon" --jobs 1 --full >/dev/null 2>&1
+"$SCANNER_BIN" --path "$TEST_DIR" --output "$TEST_DIR/jobs8.toon" --jobs 8 --full >/dev/null 2>&1
+JOBS_DIFF=$(diff <(grep -v "^META:lastUpdated=" "$TEST_DIR/jobs1.toon") <(grep -v "^META:lastUpdated=" "$TEST_DIR/jobs8.toon") || true)
+if [ -s "$TEST_DIR/jobs1.toon" ] && [ -z "$JOBS_DIFF" ]; then
+    pass "Writes the same graph for -jobs 1 and -jobs 8"
+else
+    fail "Parallel scan" "Graphs differ: $JOBS_DIFF"
+fi
+
+# Test 31: Incremental scans with the parse cache
+echo ""
+echo "Testing parse cache..."
+CACHE_DIR="$TEST_DIR/fixtures/cache"
+CACHE_GRAPH="$TEST_DIR/cache.toon"
+mkdir -p "$CACHE_DIR"
+echo 'export const b = 1;' > "$CACHE_DIR/b.ts"
+echo 'export const c = 1;' > "$CACHE_DIR/c.ts"
+echo "import { b } from './b';" > "$CACHE_DIR/a.ts"
+cache_scan() {
+    "$SCANNER_BIN" --path "$CACHE_DIR" --output "$CACHE_GRAPH" --verbose "$@" 2>&1 | grep "^Scan complete"
+}
+CACHE_FIRST=$(cache_scan)
+CACHE_AGAIN=$(cache_scan)
+echo "import { c } from './c'; // edited" > "$CACHE_DIR/a.ts"
+CACHE_EDITED=$(cache_scan)
+CACHE_IMPORTS=$(toon_get_imports "$CACHE_GRAPH" "$CACHE_DIR/a.ts")
+CACHE_FULL=$(cache_scan --full)
+sed -i.bak 's/"version":[0-9]*/"version":1/' "$CACHE_DIR/.claude/dep-cache/files.json"
+CACHE_OLD=$(cache_scan)
+if [[ "$CACHE_FIRST" == *"(3 parsed, 0 from cache)"* ]] && \
+   [[ "$CACHE_AGAIN" == *"(0 parsed, 3 from cache)"* ]] && \
+   [[ "$CACHE_EDITED" == *"(1 parsed, 2 from cache)"* ]] && \
+   echo "$CACHE_IMPORTS" | grep -qF "$CACHE_DIR/c.ts:1:" && \
+   ! echo "$CACHE_IMPORTS" | grep -qF "$CACHE_DIR/b.ts" && \
+   [[ "$CACHE_FULL" == *"(3 parsed, 0 from cache)"* ]] && \
+   [[ "$CACHE_OLD" == *"(3 parsed, 0 from cache)"* ]]; then
+    pass "Reuses unchanged files and re-parses edited ones, with -full or an outdated cache"
+else
+    fail "Parse cache" "Got: $CACHE_FIRST / $CACHE_AGAIN / $CACHE_EDITED / $CACHE_IMPORTS / $CACHE_FULL / $CACHE_OLD"
+fi
+
+# Test 32: Watch mode rewrites the graph when a file changes
+echo ""
+echo "Testing watch mode..."
+WATCH_OK=true
+WATCH_GOT=""
+for WATCH_MODE in native poll; do
+    WATCH_DIR="$TEST_DIR/fixtures/watch-$WATCH_MODE"
+    WATCH_GRAPH="$TEST_DIR/watch-$WATCH_MODE.toon"
+    mkdir -p "$WATCH_DIR"
+    echo 'export const b = 1;' > "$WATCH_DIR/b.ts"
+    echo 'export const a = 1;' > "$WATCH_DIR/a.ts"
+    WATCH_FLAGS=(--path "$WATCH_DIR" --output "$WATCH_GRAPH" --watch --interval 100ms --debounce 50ms)
+    if [ "$WATCH_MODE" = "poll" ]; then
+        WATCH_FLAGS+=(--poll)
+    fi
+    "$SCANNER_BIN" "${WATCH_FLAGS[@]}" >/dev/null 2>&1 &
+    WATCH_PID=$!
+    for _ in $(seq 1 50); do
+        [ -s "$WATCH_GRAPH" ] && break
+        sleep 0.1
+    done
+    echo "import { b } from './b';" > "$WATCH_DIR/a.ts"
+    WATCH_UPDATED=false
+    for _ in $(seq 1 50); do
+        if toon_get_imports "$WATCH_GRAPH" "$WATCH_DIR/a.ts" | grep -qF "$WATCH_DIR/b.ts:1:"; then
+            WATCH_UPDATED=true
+            break
+        fi
+        sleep 0.1
+    done
+    kill "$WATCH_PID" 2>/dev/null || true
+    wait "$WATCH_PID" 2>/dev/null || true
+    if ! $WATCH_UPDATED; then
+        WATCH_OK=false
+        WATCH_GOT="$WATCH_GOT [$WATCH_MODE] $(toon_get_imports "$WATCH_GRAPH" "$WATCH_DIR/a.ts")"
+    fi
+done
+if $WATCH_OK; then
+    pass "Updates the graph on changes with native notifications and with -poll"
+else
+    fail "Watch mode" "Graph not rewritten:$WATCH_GOT"
+fi
+
+# Test 33: Extensionless scripts are parsed under their shebang language
+echo ""
+echo "Testing shebang scripts..."
+SHEBANG_DIR="$TEST_DIR/fixtures/shebang"
+mkdir -p "$SHEBANG_DIR/bin"
+printf '#!/usr/bin/env python3\nimport helper\n\nhelper.run()\n' > "$SHEBANG_DIR/bin/tool"
+echo 'def run(): pass' > "$SHEBANG_DIR/helper.py"
+SHEBANG_GRAPH="$TEST_DIR/shebang.toon"
+"$SCANNER_BIN" --path "$SHEBANG_DIR" --output "$SHEBANG_GRAPH" >/dev/null 2>&1
+SHEBANG_DEAD=$("$SCANNER_BIN" query -graph "$SHEBANG_GRAPH" dead 2>&1 || true)
+if [ "$(toon_get_language "$SHEBANG_GRAPH" "$SHEBANG_DIR/bin/tool")" = "python" ] && \
+   toon_get_imports "$SHEBANG_GRAPH" "$SHEBANG_DIR/bin/tool" | grep -qF "$SHEBANG_DIR/helper.py:2:" && \
+   ! echo "$SHEBANG_DEAD" | grep -q "helper.py"; then
+    pass "Scans extensionless scripts as entry points in their shebang's language"
+else
+    fail "Shebang scripts" "Got: $(toon_get_imports "$SHEBANG_GRAPH" "$SHEBANG_DIR/bin/tool") / $SHEBANG_DEAD"
+fi
+
+# Test 34: Break-edge suggestions leave a dense import tangle acyclic
+echo ""
+echo "Testing break-edge suggestions on a dense tangle..."
+TANGLE_DIR="$TEST_DIR/fixtures/tangle"
+TANGLE_SIZE=1000
+mkdir -p "$TANGLE_DIR"
+# Every file imports four others; some import the same file on several lines
+for i in $(seq 0 $((TANGLE_SIZE - 1))); do
+    printf "import { a } from './f%d';\nimport { b } from './f%d';\nimport { c } from './f%d';\nimport { d } from './f%d';\nexport const f%d = 1;\n" \
+        $(( (i + 1) % TANGLE_SIZE )) $(( (i + 7) % TANGLE_SIZE )) $(( (i * 7 + 3) % TANGLE_SIZE )) $(( (i * 13 + 5) % TANGLE_SIZE )) "$i" > "$TANGLE_DIR/f$i.ts"
+done
+TANGLE_GRAPH="$TEST_DIR/tangle.toon"
+"$SCANNER_BIN" --path "$TANGLE_DIR" --output "$TANGLE_GRAPH" >/dev/null 2>&1
+TANGLE_CUTS=$("$SCANNER_BIN" query -graph "$TANGLE_GRAPH" cycles 2>&1 | sed -n 's/^  - \(.*\):\([0-9]*\) import of .*/\1 \2/p' || true)
+TANGLE_CUT_COUNT=$(echo "$TANGLE_CUTS" | grep -c . || true)
+# Comment out each suggested import and check that no cycle is left
+echo "$TANGLE_CUTS" | while read -r CUT_FILE CUT_LINE; do
+    [ -n "$CUT_FILE" ] && sed -i.bak "${CUT_LINE}s#^#// #" "$CUT_FILE"
+done
+rm -f "$TANGLE_DIR"/*.bak
+"$SCANNER_BIN" --path "$TANGLE_DIR" --output "$TANGLE_GRAPH" >/dev/null 2>&1
+TANGLE_LEFT=$(toon_get_circular "$TANGLE_GRAPH" | wc -l | tr -d ' ')
+if [ "$TANGLE_CUT_COUNT" -gt 0 ] && [ "$TANGLE_CUT_COUNT" -lt $((TANGLE_SIZE * 2)) ] && [ "$TANGLE_LEFT" -eq 0 ]; then
+    pass "Suggests a minority of imports whose removal breaks every cycle"
+else
+    fail "Break-edge suggestions" "$TANGLE_CUT_COUNT of $((TANGLE_SIZE * 4)) imports suggested, $TANGLE_LEFT cycle(s) left"
+fi
+
+# Test 35: A very deep import cycle, reported the same way on every scan
+echo ""
+echo "Testing deep import cycles..."
+CHAIN_DIR="$TEST_DIR/fixtures/chain"
+CHAIN_SIZE=20000
+mkdir -p "$CHAIN_DIR"
+for i in $(seq 0 $((CHAIN_SIZE - 1))); do
+    NEXT=$(( (i + 1) % CHAIN_SIZE ))
+    printf "import { f%d } from './f%d';\nexport const f%d = 1;\n" "$NEXT" "$NEXT" "$i" > "$CHAIN_DIR/f$i.ts"
+done
+CHAIN_STATUS=0
+"$SCANNER_BIN" --path "$CHAIN_DIR" --output "$TEST_DIR/chain1.toon" --full >/dev/null 2>&1 || CHAIN_STATUS=$?
+"$SCANNER_BIN" --path "$CHAIN_DIR" --output "$TEST_DIR/chain2.toon" --full --jobs 4 >/dev/null 2>&1 || CHAIN_STATUS=$?
+CHAIN_CYCLES=$("$SCANNER_BIN" query -graph "$TEST_DIR/chain1.toon" cycles 2>&1 | head -3 || true)
+if [ "$CHAIN_STATUS" -eq 0 ] && \
+   echo "$CHAIN_CYCLES" | grep -q "^Cycle 1 ($CHAIN_SIZE files)" && \
+   [ -n "$(grep "^CIRCULAR:" "$TEST_DIR/chain1.toon")" ] && \
+   cmp -s <(grep "^CIRCULAR:" "$TEST_DIR/chain1.toon") <(grep "^CIRCULAR:" "$TEST_DIR/chain2.toon"); then
+    pass "Finds a $CHAIN_SIZE-file cycle and reports it identically on every scan"
+else
+    fail "Deep import cycles" "Exit $CHAIN_STATUS: $CHAIN_CYCLES"
+fi
+
+# Test 36: Impact analysis filters, distances and test marking
+echo ""
+echo "Testing impact analysis options..."
+IMPACT_DIR="$TEST_DIR/fixtures/impact"
+mkdir -p "$IMPACT_DIR"
+echo 'export type T = string; export const core = 1;' > "$IMPACT_DIR/core.ts"
+echo "import type { T } from './core';" > "$IMPACT_DIR/types-user.ts"
+echo "import { core } from './core'; export const a = core;" > "$IMPACT_DIR/a.ts"
+echo "import { a } from './a'; export const b = a;" > "$IMPACT_DIR/b.ts"
+echo "import { b } from './b'; export const c = b;" > "$IMPACT_DIR/c.ts"
+echo "import { core } from './core';" > "$IMPACT_DIR/core.test.ts"
+echo "const { core } = require('./core');" > "$IMPACT_DIR/legacy.js"
+IMPACT_GRAPH="$TEST_DIR/impact.toon"
+"$SCANNER_BIN" --path "$IMPACT_DIR" --output "$IMPACT_GRAPH" >/dev/null 2>&1
+impact_of() {
+    "$SCANNER_BIN" query -graph "$IMPACT_GRAPH" "$@" impact "$IMPACT_DIR/core.ts" 2>&1 || true
+}
+IMPACT_ALL=$(impact_of)
+IMPACT_DEPTH=$(impact_of -depth 2)
+IMPACT_VALUES=$(impact_of -skip-kinds type-only)
+IMPACT_STATIC=$(impact_of -kinds static)
+IMPACT_JS=$(impact_of -languages javascript)
+if echo "$IMPACT_ALL" | grep -qF "$IMPACT_DIR/core.test.ts [test]" && \
+   echo "$IMPACT_ALL" | grep -qF "$IMPACT_DIR/b.ts (distance 2, via $IMPACT_DIR/a.ts)" && \
+   echo "$IMPACT_ALL" | grep -qF "$IMPACT_DIR/c.ts (distance 3, via $IMPACT_DIR/a.ts → $IMPACT_DIR/b.ts)" && \
+   echo "$IMPACT_ALL" | grep -q "Total files affected: 6 (1 test)" && \
+   echo "$IMPACT_DEPTH" | grep -qF "$IMPACT_DIR/b.ts" && ! echo "$IMPACT_DEPTH" | grep -qF "$IMPACT_DIR/c.ts" && \
+   ! echo "$IMPACT_VALUES" | grep -qF "types-user.ts" && echo "$IMPACT_VALUES" | grep -qF "$IMPACT_DIR/c.ts" && \
+   ! echo "$IMPACT_STATIC" | grep -qF "legacy.js" && echo "$IMPACT_STATIC" | grep -qF "$IMPACT_DIR/a.ts" && \
+   echo "$IMPACT_JS" | grep -q "Total files affected: 1 (0 tests)" && echo "$IMPACT_JS" | grep -qF "$IMPACT_DIR/legacy.js"; then
+    pass "Limits impact by depth, import kind and language, with distances and tests marked"
+else
+    fail "Impact analysis options" "Got: $IMPACT_ALL / $IMPACT_DEPTH / $IMPACT_VALUES / $IMPACT_STATIC / $IMPACT_JS"
+fi
+
+# Test 37: k shortest and all import chains between two files
+echo ""
+echo "Testing multi-path queries..."
+ROUTES_DIR="$TEST_DIR/fixtures/routes"
+mkdir -p "$ROUTES_DIR"
+echo "import { short } from './short'; import { mid1 } from './mid1';" > "$ROUTES_DIR/app.ts"
+echo "import { db } from './db'; export const short = 1;" > "$ROUTES_DIR/short.ts"
+echo "import { mid2 } from './mid2'; export const mid1 = 1;" > "$ROUTES_DIR/mid1.ts"
+echo "import { db } from './db'; export const mid2 = 1;" > "$ROUTES_DIR/mid2.ts"
+echo "export const db = 1;" > "$ROUTES_DIR/db.ts"
+ROUTES_GRAPH="$TEST_DIR/routes.toon"
+"$SCANNER_BIN" --path "$ROUTES_DIR" --output "$ROUTES_GRAPH" >/dev/null 2>&1
+routes() {
+    "$SCANNER_BIN" query -graph "$ROUTES_GRAPH" "$@" path "$ROUTES_DIR/app.ts" "$ROUTES_DIR/db.ts" 2>&1 || true
+}
+ROUTES_K2=$(routes -k 2)
+ROUTES_HOPS2=$(routes -all -max-hops 2)
+ROUTES_HOPS3=$(routes -all -max-hops 3)
+if echo "$ROUTES_K2" | grep -A1 "^Path 1 (2 hops):" | grep -qF "$ROUTES_DIR/app.ts:1 imports $ROUTES_DIR/short.ts" && \
+   echo "$ROUTES_K2" | grep -A3 "^Path 2 (3 hops):" | grep -qF "$ROUTES_DIR/mid1.ts:1 imports $ROUTES_DIR/mid2.ts" && \
+   echo "$ROUTES_HOPS2" | grep -qF "$ROUTES_DIR/short.ts:1 imports $ROUTES_DIR/db.ts" && \
+   ! echo "$ROUTES_HOPS2" | grep -qF "mid1.ts" && \
+   echo "$ROUTES_HOPS3" | grep -q "^Path 2 (3 hops):"; then
+    pass "Lists the k shortest chains and every chain within -max-hops"
+else
+    fail "Multi-path queries" "Got: $ROUTES_K2 / $ROUTES_HOPS2 / $ROUTES_HOPS3"
+fi
+
 # Cleanup
 cd /
 rm -rf "$TEST_DIR"
@@ -175,13 +175,7 @@ fi
 print_test "10" "Pre-edit-analysis hook can analyze file impact"
 
 # Create a mock dependency graph for testing
-TEMP_DEP_GRAPH="$HOME/.claude/dep-graph.toon"
-TEMP_DIR_CREATED=false
-
-if [ ! -d "$HOME/.claude" ]; then
-    mkdir -p "$HOME/.claude"
-    TEMP_DIR_CREATED=true
-fi
+TEMP_DEP_GRAPH=$(mktemp)
 
 cat > "$TEMP_DEP_GRAPH" << 'EOF'
 FILE:/tmp/test-file.ts
@@ -192,13 +186,10 @@ IMPORTEDBY:/tmp/other-file.ts,/tmp/another-file.ts
 ---
 EOF
 
-OUTPUT=$(./hooks/pre-edit-analysis.sh "/tmp/test-file.ts" 2>/dev/null || true)
+OUTPUT=$(DEP_GRAPH_FILE="$TEMP_DEP_GRAPH" ./hooks/pre-edit-analysis.sh "/tmp/test-file.ts" 2>/dev/null || true)
 
 # Cleanup
 rm -f "$TEMP_DEP_GRAPH"
-if $TEMP_DIR_CREATED; then
-    rmdir "$HOME/.claude" 2>/dev/null || true
-fi
 
 if echo "$OUTPUT" | grep -q "Impact Analysis"; then
     pass "Pre-edit-analysis correctly analyzes file impact"
@@ -1,92 +1,163 @@
 package main
 
+import "sort"
+
 // DetectCircularDependencies finds circular dependency cycles using Tarjan's algorithm
-// Returns list of cycles where each cycle is a list of file paths
+// Returns one cycle per strongly connected component, listed in import order:
+// each file imports the next and the last imports the first
 func DetectCircularDependencies(graph *DependencyGraph) [][]string {
-	// Build adjacency list
-	adj := make(map[string][]string)
-	for path, node := range graph.Files {
-		adj[path] = []string{}
-		for _, imp := range node.Imports {
-			// Only consider imports that exist in our graph
-			if _, exists := graph.Files[imp.Path]; exists {
-				adj[path] = append(adj[path], imp.Path)
-			}
+	edges := importEdges(graph)
+
+	cycles := [][]string{}
+	for _, scc := range stronglyConnected(graph, edges) {
+		inner := componentEdges(scc, edges)
+		cycle := []string{}
+		for _, hop := range shortestCycle(inner, scc[0]) {
+			cycle = append(cycle, hop.File)
 		}
+		cycles = append(cycles, cycle)
 	}
+	return cycles
+}
+
+// idGraph is the import graph over integer node IDs in compressed sparse
+// row form. IDs follow the lexical order of paths and each node's edges
+// keep source order, so every traversal is deterministic.
+type idGraph struct {
+	paths   []string // Node ID -> file path
+	offsets []int    // Edges of node v are targets[offsets[v]:offsets[v+1]]
+	targets []int
+}
+
+func newIDGraph(graph *DependencyGraph, edges map[string][]importEdge) *idGraph {
+	paths := graph.SortedPaths()
+	ids := make(map[string]int, len(paths))
+	for id, path := range paths {
+		ids[path] = id
+	}
+
+	g := &idGraph{paths: paths, offsets: make([]int, len(paths)+1)}
+	for id, path := range paths {
+		for _, edge := range edges[path] {
+			g.targets = append(g.targets, ids[edge.to])
+		}
+		g.offsets[id+1] = len(g.targets)
+	}
+	return g
+}
+
+func (g *idGraph) successors(v int) []int {
+	return g.targets[g.offsets[v]:g.offsets[v+1]]
+}
+
+// components returns the strongly connected components using an iterative
+// form of Tarjan's algorithm, in O(V+E) time and memory. Each component
+// lists its nodes in ascending order.
+func (g *idGraph) components() [][]int {
+	n := len(g.paths)
+	index := make([]int, n)
+	lowlink := make([]int, n)
+	onStack := make([]bool, n)
+	for v := range index {
+		index[v] = -1
+	}
+
+	// frame is a suspended visit of v, resuming at edge position next
+	type frame struct{ v, next int }
+	var calls []frame
+	var stack []int
+	var sccs [][]int
+	counter := 0
 
-	// Tarjan's algorithm state
-	index := 0
-	stack := []string{}
-	onStack := make(map[string]bool)
-	indices := make(map[string]int)
-	lowlinks := make(map[string]int)
-	sccs := [][]string{}
-
-	// Recursive strongconnect function
-	var strongconnect func(string)
-	strongconnect = func(v string) {
-		indices[v] = index
-		lowlinks[v] = index
-		index++
+	visit := func(v int) {
+		index[v] = counter
+		lowlink[v] = counter
+		counter++
 		stack = append(stack, v)
 		onStack[v] = true
+		calls = append(calls, frame{v, g.offsets[v]})
+	}
 
-		// Consider successors
-		for _, w := range adj[v] {
-			if _, visited := indices[w]; !visited {
-				strongconnect(w)
-				if lowlinks[w] < lowlinks[v] {
-					lowlinks[v] = lowlinks[w]
-				}
-			} else if onStack[w] {
-				if indices[w] < lowlinks[v] {
-					lowlinks[v] = indices[w]
+	for root := 0; root < n; root++ {
+		if index[root] != -1 {
+			continue
+		}
+		visit(root)
+
+		for len(calls) > 0 {
+			top := &calls[len(calls)-1]
+			v := top.v
+
+			// Consider the next successor
+			if top.next < g.offsets[v+1] {
+				w := g.targets[top.next]
+				top.next++
+				if index[w] == -1 {
+					visit(w)
+				} else if onStack[w] && index[w] < lowlink[v] {
+					lowlink[v] = index[w]
 				}
+				continue
 			}
-		}
 
-		// If v is a root node, pop the stack and generate an SCC
-		if lowlinks[v] == indices[v] {
-			scc := []string{}
-			for {
-				w := stack[len(stack)-1]
-				stack = stack[:len(stack)-1]
-				onStack[w] = false
-				scc = append(scc, w)
-				if w == v {
-					break
+			// All successors done: return to the caller
+			calls = calls[:len(calls)-1]
+			if len(calls) > 0 {
+				if parent := calls[len(calls)-1].v; lowlink[v] < lowlink[parent] {
+					lowlink[parent] = lowlink[v]
 				}
 			}
-			// Only add if it's a cycle (more than 1 node)
-			if len(scc) > 1 {
+
+			// If v is a root node, pop the stack and generate an SCC
+			if lowlink[v] == index[v] {
+				i := len(stack) - 1
+				for stack[i] != v {
+					i--
+				}
+				scc := append([]int{}, stack[i:]...)
+				for _, w := range scc {
+					onStack[w] = false
+				}
+				stack = stack[:i]
+				sort.Ints(scc)
 				sccs = append(sccs, scc)
 			}
 		}
 	}
 
-	// Run algorithm on all nodes
-	for path := range graph.Files {
-		if _, visited := indices[path]; !visited {
-			strongconnect(path)
+	return sccs
+}
+
+// stronglyConnected returns the strongly connected components of the
+// import graph that contain a cycle: several files, or one file importing
+// itself. Components and their files are sorted.
+func stronglyConnected(graph *DependencyGraph, edges map[string][]importEdge) [][]string {
+	g := newIDGraph(graph, edges)
+
+	var sccs [][]string
+	for _, ids := range g.components() {
+		if len(ids) == 1 && !g.importsItself(ids[0]) {
+			continue
+		}
+		scc := make([]string, len(ids))
+		for i, id := range ids {
+			scc[i] = g.paths[id]
 		}
+		sccs = append(sccs, scc)
 	}
 
+	// Ascending IDs follow path order
+	sort.Slice(sccs, func(i, j int) bool {
+		return sccs[i][0] < sccs[j][0]
+	})
 	return sccs
 }
 
-// DetectDeadCode finds files that are not imported by any other file
-// These are potential entry points or unused code
-func DetectDeadCode(graph *DependencyGraph) []string {
-	deadCode := []string{}
-
-	for path, node := range graph.Files {
-		// If no one imports this file, it might be dead code
-		// Exception: entry points like main.go, index.ts typically have no importers
-		if len(node.ImportedBy) == 0 {
-			deadCode = append(deadCode, path)
+func (g *idGraph) importsItself(v int) bool {
+	for _, w := range g.successors(v) {
+		if w == v {
+			return true
 		}
 	}
-
-	return deadCode
+	return false
 }
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// parseCacheVersion must be bumped whenever import/export extraction
+// changes so stale entries from older scanners are discarded
+const parseCacheVersion = 4
+
+// parseCacheFile is the file inside the cache directory holding all entries
+const parseCacheFile = "files.json"
+
+// cacheEntry is the parse result of one file together with the stat and
+// content hash it was computed from. Imports are stored unresolved.
+type cacheEntry struct {
+	Size     int64    `json:"size"`
+	ModTime  int64    `json:"mtime"`
+	Hash     string   `json:"hash"`
+	Language string   `json:"language"`
+	Imports  []Import `json:"imports"`
+	Exports  []Export `json:"exports"`
+	Entry    string   `json:"entry,omitempty"` // Entry point detected from the source
+}
+
+// parseCache persists parse results between runs so that only changed,
+// added or deleted files need to be re-parsed
+type parseCache struct {
+	Version   int                    `json:"version"`
+	Languages string                 `json:"languages"`
+	Files     map[string]*cacheEntry `json:"files"`
+
+	dir string
+}
+
+// loadParseCache reads the cache from dir. A missing, unreadable or
+// incompatible cache yields an empty one.
+func loadParseCache(dir string, languages []string) *parseCache {
+	cache := &parseCache{
+		Version:   parseCacheVersion,
+		Languages: strings.Join(languages, ","),
+		Files:     make(map[string]*cacheEntry),
+		dir:       dir,
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, parseCacheFile))
+	if err != nil {
+		return cache
+	}
+
+	var stored parseCache
+	if err := json.Unmarshal(content, &stored); err != nil {
+		return cache
+	}
+	if stored.Version != cache.Version || stored.Languages != cache.Languages || stored.Files == nil {
+		return cache
+	}
+
+	cache.Files = stored.Files
+	return cache
+}
+
+// lookup returns the cached node for path if its size and mtime are
+// unchanged. A nil cache never hits.
+func (c *parseCache) lookup(path string, info os.FileInfo) *FileNode {
+	if c == nil {
+		return nil
+	}
+	entry, ok := c.Files[path]
+	if !ok || entry.Size != info.Size() || entry.ModTime != info.ModTime().UnixNano() {
+		return nil
+	}
+	return entry.node(path)
+}
+
+// lookupHash returns the cached node for path if its content hash is
+// unchanged, e.g. after a checkout touched the mtime only
+func (c *parseCache) lookupHash(path, hash string) *FileNode {
+	if c == nil {
+		return nil
+	}
+	entry, ok := c.Files[path]
+	if !ok || entry.Hash != hash {
+		return nil
+	}
+	return entry.node(path)
+}
+
+func (e *cacheEntry) node(path string) *FileNode {
+	imports := e.Imports
+	if imports == nil {
+		imports = []Import{}
+	}
+	exports := e.Exports
+	if exports == nil {
+		exports = []Export{}
+	}
+	return &FileNode{
+		Path:       path,
+		Language:   e.Language,
+		EntryPoint: e.Entry,
+		Imports:    imports,
+		Exports:    exports,
+		ImportedBy: []string{},
+	}
+}
+
+// save writes the entries for the files of the current scan, which drops
+// deleted files, replacing the previous cache atomically
+func (c *parseCache) save(entries map[string]*cacheEntry) error {
+	c.Files = entries
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(c.dir, parseCacheFile), data)
+}
+
+// newCacheEntry captures a freshly parsed node before import resolution
+func newCacheEntry(node *FileNode, info os.FileInfo, hash string) *cacheEntry {
+	return &cacheEntry{
+		Size:     info.Size(),
+		ModTime:  info.ModTime().UnixNano(),
+		Hash:     hash,
+		Language: node.Language,
+		Imports:  node.Imports,
+		Exports:  node.Exports,
+		Entry:    node.EntryPoint,
+	}
+}
+
+func hashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place so readers never observe a partial file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
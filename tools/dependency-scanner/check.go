@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// checkUsage describes the check subcommand
+const checkUsage = `Usage: dependency-scanner check [flags]
+
+Scans the project and checks it against the "rules" and "noNewCycles"
+settings of .claude/dep-scanner.json, printing each violation with the
+importing file and line. Exits 1 if any rule is broken and 2 if the check
+could not run, so hooks can block changes that cross a boundary. With -hook,
+violations only exit 1 if the config also sets "blockOnViolations".
+
+Only the quality-check hook blocks, after a response, on the files it
+changed. The pre-edit hook sees a file before the edit and lists its
+violations without blocking, since blocking would also stop the edits that
+fix them.
+
+Example config:
+  "rules": [
+    {"from": "domain/**", "disallow": ["infra/**"]},
+    {"from": "packages/ui", "allow": ["packages/shared"], "reason": "UI talks to the API through shared"}
+  ],
+  "noNewCycles": true,
+  "blockOnViolations": true
+
+Flags:
+`
+
+// runCheck evaluates the architecture rules and returns the process exit
+// code
+func runCheck(args []string) int {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	pathFlag := flags.String("path", ".", "Project to check")
+	graphFlag := flags.String("graph", "", "Check a saved graph instead of scanning the project")
+	baselineFlag := flags.String("baseline", "", "Graph whose cycles noNewCycles accepts (default: the project's saved graph)")
+	filesFlag := flags.String("files", "", "Only report violations in these comma-separated files")
+	jsonFlag := flags.Bool("json", false, "Print the violations as JSON")
+	hookFlag := flags.Bool("hook", false, "Exit 1 on violations only if the project config sets blockOnViolations")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), checkUsage)
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Error: check takes no arguments, got %q\n\n", flags.Arg(0))
+		flags.Usage()
+		return 2
+	}
+
+	config, err := loadProjectConfig(*pathFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to load project config: %v\n", err)
+		return 2
+	}
+	if len(config.Rules) == 0 && !config.NoNewCycles && !*jsonFlag {
+		fmt.Printf("No architecture rules configured in %s\n", filepath.Join(*pathFlag, projectConfigFile))
+		return 0
+	}
+
+	graph, baselinePath, err := checkedGraph(*pathFlag, *graphFlag, config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 2
+	}
+	if *baselineFlag != "" {
+		baselinePath = *baselineFlag
+	}
+
+	violations := CheckArchitecture(graph, config.Rules)
+	if config.NoNewCycles {
+		var baseline *DependencyGraph
+		if baselinePath != "" {
+			baseline, err = LoadGraph(baselinePath)
+			if os.IsNotExist(err) && *baselineFlag == "" {
+				// Nothing saved yet, so every cycle is new
+				baseline = nil
+			} else if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: Failed to load baseline graph: %v\n", err)
+				return 2
+			}
+		}
+		violations = append(violations, NewCycles(graph, baseline)...)
+	}
+
+	if files := splitPatterns(*filesFlag); len(files) > 0 {
+		violations = violationsIn(graph, violations, files)
+	}
+	sortViolations(violations)
+
+	if *jsonFlag {
+		data, err := json.MarshalIndent(struct{ Violations []Violation }{violations}, "", "  ")
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 2
+		}
+		fmt.Println(string(data))
+	} else {
+		printViolations(violations, len(config.Rules), config.NoNewCycles)
+	}
+
+	if len(violations) > 0 && (!*hookFlag || config.BlockOnViolations) {
+		return 1
+	}
+	return 0
+}
+
+// checkedGraph scans the project, or loads the saved graph given by
+// graphPath, and returns it with the default baseline for noNewCycles:
+// the project's saved graph when scanning, none otherwise
+func checkedGraph(root, graphPath string, config *ProjectConfig) (*DependencyGraph, string, error) {
+	if graphPath != "" {
+		graph, err := LoadGraph(graphPath)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to load graph: %w", err)
+		}
+		return graph, "", nil
+	}
+
+	// Scan by absolute path so that -files accepts absolute paths too
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return nil, "", err
+	}
+	scanner, err := NewScanner(root, ScannerOptions{
+		Languages:   config.Languages,
+		EntryPoints: config.EntryPoints,
+		Aliases:     config.Aliases,
+		Excludes:    config.Exclude,
+		Includes:    config.Include,
+		CacheDir:    config.cacheDir(root),
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create scanner: %w", err)
+	}
+	if err := scanner.Scan(); err != nil {
+		return nil, "", fmt.Errorf("scan failed: %w", err)
+	}
+	return scanner.GetGraph(), config.outputPath(root), nil
+}
+
+// violationsIn keeps the violations of the given files. Files missing
+// from the graph have none.
+func violationsIn(graph *DependencyGraph, violations []Violation, files []string) []Violation {
+	wanted := make(map[string]bool, len(files))
+	for _, file := range files {
+		if path, err := graph.findFile(file); err == nil {
+			wanted[graph.relPath(path)] = true
+		}
+	}
+
+	kept := []Violation{}
+	for _, v := range violations {
+		if wanted[v.File] {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// printViolations lists violations as file:line with the rule they break
+func printViolations(violations []Violation, rules int, noNewCycles bool) {
+	checks := plural(rules, "rule")
+	if noNewCycles {
+		checks += " and " + ruleNoNewCycles
+	}
+
+	if len(violations) == 0 {
+		fmt.Printf("No architecture violations (%s checked)\n", checks)
+		return
+	}
+
+	for _, v := range violations {
+		fmt.Printf("%s:%d: imports %s\n", v.File, v.Line, v.Import)
+		if v.Detail != "" {
+			fmt.Printf("    %s: %s\n", v.Rule, v.Detail)
+		} else {
+			fmt.Printf("    %s\n", v.Rule)
+		}
+	}
+	fmt.Println()
+	fmt.Printf("Found %d architecture violation(s) (%s checked)\n", len(violations), checks)
+}
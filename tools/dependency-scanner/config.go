@@ -0,0 +1,159 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// projectConfigFile is the per-project scanner configuration, relative to
+// the scanned directory
+const projectConfigFile = ".claude/dep-scanner.json"
+
+// Output formats accepted by ProjectConfig.Format and -format
+const (
+	FormatTOON = "toon"
+	FormatJSON = "json"
+)
+
+// Diagram and report formats accepted by -format only, as they can't be
+// read back
+const (
+	FormatDOT     = "dot"
+	FormatMermaid = "mermaid"
+	FormatHTML    = "html"
+)
+
+// ProjectConfig is the contents of .claude/dep-scanner.json. Relative paths
+// are resolved against the project root; command-line flags take precedence
+// over every field.
+type ProjectConfig struct {
+	Languages         []string   `json:"languages"`         // Grammars to load
+	Exclude           []string   `json:"exclude"`           // gitignore-style patterns to skip
+	Include           []string   `json:"include"`           // If set, only matching files are scanned
+	EntryPoints       []string   `json:"entryPoints"`       // Files or globs that are used without being imported
+	Aliases           aliasMap   `json:"aliases"`           // Import aliases such as "@/*": "src/*"
+	Output            string     `json:"output"`            // Graph file (default .claude/dep-graph.toon)
+	Format            string     `json:"format"`            // "toon" or "json" (default: from the output extension)
+	Cache             string     `json:"cache"`             // Parse cache directory (default .claude/dep-cache)
+	Rules             []ArchRule `json:"rules"`             // Import restrictions enforced by the check command
+	NoNewCycles       bool       `json:"noNewCycles"`       // check fails on import cycles missing from the saved graph
+	BlockOnViolations bool       `json:"blockOnViolations"` // The quality-check hook blocks responses that break a rule
+
+	path string // File the config was read from, empty if there is none
+}
+
+// aliasMap maps an import pattern to one or more target patterns, accepting
+// both "@/*": "src/*" and tsconfig-style "@/*": ["src/*", "lib/*"]
+type aliasMap map[string][]string
+
+func (a *aliasMap) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	aliases := make(aliasMap, len(raw))
+	for pattern, value := range raw {
+		var target string
+		if err := json.Unmarshal(value, &target); err == nil {
+			aliases[pattern] = []string{target}
+			continue
+		}
+		var targets []string
+		if err := json.Unmarshal(value, &targets); err != nil {
+			return fmt.Errorf("alias %q: expected a string or an array of strings", pattern)
+		}
+		aliases[pattern] = targets
+	}
+
+	*a = aliases
+	return nil
+}
+
+// loadProjectConfig reads .claude/dep-scanner.json below root. A missing
+// file yields an empty config; comments and trailing commas are allowed.
+func loadProjectConfig(root string) (*ProjectConfig, error) {
+	path := filepath.Join(root, projectConfigFile)
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &ProjectConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := ProjectConfig{path: path}
+	if err := json.Unmarshal(stripJSONComments(content), &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	switch cfg.Format {
+	case "", FormatTOON, FormatJSON:
+	default:
+		return nil, fmt.Errorf("%s: unknown format %q (want %q or %q)", path, cfg.Format, FormatTOON, FormatJSON)
+	}
+
+	for i, rule := range cfg.Rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("%s: rule %d: %w", path, i+1, err)
+		}
+	}
+
+	return &cfg, nil
+}
+
+// outputPath returns the graph file, relative to root unless absolute. The
+// default extension follows the configured format.
+func (c *ProjectConfig) outputPath(root string) string {
+	if c.Output != "" {
+		return projectPath(root, c.Output)
+	}
+	if c.Format == FormatJSON {
+		return filepath.Join(root, ".claude", "dep-graph.json")
+	}
+	return filepath.Join(root, ".claude", "dep-graph.toon")
+}
+
+// cacheDir returns the parse cache directory, relative to root unless absolute
+func (c *ProjectConfig) cacheDir(root string) string {
+	if c.Cache != "" {
+		return projectPath(root, c.Cache)
+	}
+	return filepath.Join(root, ".claude", "dep-cache")
+}
+
+// projectPath resolves a config path against the project root
+func projectPath(root, path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(root, path)
+}
+
+// outputFormat picks the format for an output file: an explicit format
+// wins, otherwise .json selects JSON, .dot or .gv DOT, .mmd Mermaid and
+// .html the HTML report
+func outputFormat(format, outputPath string) string {
+	if format != "" {
+		return format
+	}
+	switch filepath.Ext(outputPath) {
+	case ".json":
+		return FormatJSON
+	case ".dot", ".gv":
+		return FormatDOT
+	case ".mmd", ".mermaid":
+		return FormatMermaid
+	case ".html", ".htm":
+		return FormatHTML
+	}
+	return FormatTOON
+}
@@ -0,0 +1,276 @@
+package main
+
+import "sort"
+
+// CycleReport describes one tangle of circular imports
+type CycleReport struct {
+	Files []string       // Files of the strongly connected component, sorted
+	Cycle DependencyPath // Shortest cycle through the first file, in import order
+	Break []PathHop      // Imports to remove so that no cycle remains among Files
+}
+
+// AnalyzeCycles reports every strongly connected component of the import
+// graph with a representative cycle and the imports to cut to break it
+func AnalyzeCycles(graph *DependencyGraph) []CycleReport {
+	edges := importEdges(graph)
+
+	var reports []CycleReport
+	for _, scc := range stronglyConnected(graph, edges) {
+		inner := componentEdges(scc, edges)
+		reports = append(reports, CycleReport{
+			Files: scc,
+			Cycle: shortestCycle(inner, scc[0]),
+			Break: feedbackArcSet(scc, inner),
+		})
+	}
+	return reports
+}
+
+// componentEdges keeps the imports between files of one component
+func componentEdges(scc []string, edges map[string][]importEdge) map[string][]importEdge {
+	members := make(map[string]bool, len(scc))
+	for _, file := range scc {
+		members[file] = true
+	}
+
+	inner := make(map[string][]importEdge, len(scc))
+	for _, file := range scc {
+		for _, edge := range edges[file] {
+			if members[edge.to] {
+				inner[file] = append(inner[file], edge)
+			}
+		}
+	}
+	return inner
+}
+
+// shortestCycle finds a shortest chain of imports that leads from start
+// back to itself
+func shortestCycle(edges map[string][]importEdge, start string) DependencyPath {
+	via := map[string]PathHop{start: {}}
+	queue := []string{start}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		for _, edge := range edges[current] {
+			if edge.to == start {
+				// Walk back from the closing import
+				cycle := DependencyPath{{File: current, Import: edge.imp}}
+				for file := current; file != start; file = via[file].File {
+					cycle = append(cycle, via[file])
+				}
+				for i, j := 0, len(cycle)-1; i < j; i, j = i+1, j-1 {
+					cycle[i], cycle[j] = cycle[j], cycle[i]
+				}
+				return cycle
+			}
+			if _, seen := via[edge.to]; !seen {
+				via[edge.to] = PathHop{File: current, Import: edge.imp}
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+	return nil
+}
+
+// feedbackArcSet suggests imports whose removal leaves the component
+// acyclic. Files are ordered with the Eades-Lin-Smyth heuristic, which
+// peels off sinks and sources and otherwise picks the file with the most
+// outgoing minus incoming imports; the imports pointing backwards in that
+// order form the set. Imports that would not close a cycle on their own
+// are then put back, so every suggestion is necessary, as long as those
+// checks fit a work budget linear in the size of the component. Past it,
+// the remaining backward imports are suggested unchecked. A file imported
+// on several lines is only cut off by removing all of them, so each is
+// suggested.
+func feedbackArcSet(files []string, edges map[string][]importEdge) []PathHop {
+	order := eadesOrder(files, edges)
+	position := make(map[string]int, len(order))
+	for i, file := range order {
+		position[file] = i
+	}
+
+	// Forward imports form a DAG; backward ones are candidates to cut
+	kept := make([][]int, len(order))
+	type backwardEdge struct {
+		file string
+		edge importEdge
+	}
+	var backward []backwardEdge
+	size := len(files)
+	for _, file := range files {
+		for _, edge := range edges[file] {
+			size++
+			if position[edge.to] > position[file] {
+				kept[position[file]] = append(kept[position[file]], position[edge.to])
+			} else {
+				backward = append(backward, backwardEdge{file, edge})
+			}
+		}
+	}
+
+	budget := reachBudgetFactor * size
+	var cut []PathHop
+	for _, b := range backward {
+		from, to := position[b.file], position[b.edge.to]
+		if from != to && budget > 0 && !reaches(kept, to, from, &budget) {
+			kept[from] = append(kept[from], to)
+			continue
+		}
+		for _, imp := range b.edge.statements() {
+			cut = append(cut, PathHop{File: b.file, Import: imp})
+		}
+	}
+
+	sort.Slice(cut, func(i, j int) bool {
+		if cut[i].File != cut[j].File {
+			return cut[i].File < cut[j].File
+		}
+		return cut[i].Import.Line < cut[j].Import.Line
+	})
+	return cut
+}
+
+// reachBudgetFactor bounds the files and imports feedbackArcSet visits
+// while checking backward imports, as a multiple of the component size
+const reachBudgetFactor = 16
+
+// eadesOrder orders files so that few imports point backwards. Sinks,
+// sources and files by outgoing minus incoming imports are kept in queues
+// that are updated as files are removed, so ordering takes linear time.
+func eadesOrder(files []string, edges map[string][]importEdge) []string {
+	n := len(files)
+	id := make(map[string]int, n)
+	for i, file := range files {
+		id[file] = i
+	}
+
+	in := make([]int, n)
+	out := make([]int, n)
+	successors := make([][]int, n)
+	importers := make([][]int, n)
+	for v, file := range files {
+		for _, edge := range edges[file] {
+			w, ok := id[edge.to]
+			if !ok || w == v {
+				continue
+			}
+			out[v]++
+			in[w]++
+			successors[v] = append(successors[v], w)
+			importers[w] = append(importers[w], v)
+		}
+	}
+
+	// Queues hold stale entries, skipped when popped: a file is queued
+	// again whenever its degrees change
+	removed := make([]bool, n)
+	var sinks, sources []int
+	buckets := make([][]int, 2*n+1) // Indexed by out - in + n
+	top := 0
+	enqueue := func(v int) {
+		switch {
+		case out[v] == 0:
+			sinks = append(sinks, v)
+		case in[v] == 0:
+			sources = append(sources, v)
+		default:
+			delta := out[v] - in[v] + n
+			buckets[delta] = append(buckets[delta], v)
+			if delta > top {
+				top = delta
+			}
+		}
+	}
+	for v := n - 1; v >= 0; v-- {
+		enqueue(v)
+	}
+
+	remove := func(v int) {
+		removed[v] = true
+		for _, w := range successors[v] {
+			if !removed[w] {
+				in[w]--
+				enqueue(w)
+			}
+		}
+		for _, u := range importers[v] {
+			if !removed[u] {
+				out[u]--
+				enqueue(u)
+			}
+		}
+	}
+	pop := func(queue *[]int, ok func(v int) bool) int {
+		for len(*queue) > 0 {
+			v := (*queue)[len(*queue)-1]
+			*queue = (*queue)[:len(*queue)-1]
+			if !removed[v] && ok(v) {
+				return v
+			}
+		}
+		return -1
+	}
+	isSink := func(v int) bool { return out[v] == 0 }
+	isSource := func(v int) bool { return out[v] > 0 && in[v] == 0 }
+
+	var head, tail []int
+	for len(head)+len(tail) < n {
+		if v := pop(&sinks, isSink); v >= 0 {
+			tail = append(tail, v)
+			remove(v)
+			continue
+		}
+		if v := pop(&sources, isSource); v >= 0 {
+			head = append(head, v)
+			remove(v)
+			continue
+		}
+		for ; top >= 0; top-- {
+			delta := top
+			v := pop(&buckets[delta], func(v int) bool {
+				return out[v] > 0 && in[v] > 0 && out[v]-in[v]+n == delta
+			})
+			if v >= 0 {
+				head = append(head, v)
+				remove(v)
+				break
+			}
+		}
+	}
+
+	// Sinks were collected last-first
+	order := make([]string, 0, n)
+	for _, v := range head {
+		order = append(order, files[v])
+	}
+	for i := len(tail) - 1; i >= 0; i-- {
+		order = append(order, files[tail[i]])
+	}
+	return order
+}
+
+// reaches reports whether to can be reached from from, charging each file
+// and import visited to budget. It answers true once the budget runs out.
+func reaches(edges [][]int, from, to int, budget *int) bool {
+	seen := map[int]bool{from: true}
+	stack := []int{from}
+	for len(stack) > 0 {
+		current := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if current == to {
+			return true
+		}
+		*budget -= 1 + len(edges[current])
+		if *budget <= 0 {
+			return true
+		}
+		for _, next := range edges[current] {
+			if !seen[next] {
+				seen[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return false
+}
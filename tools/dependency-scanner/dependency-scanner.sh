@@ -32,12 +32,12 @@ echo ""
 
 # Run the scanner
 "${HOME}/.claude/bin/dependency-scanner" \
+  --path "$(dirname "${CLAUDE_DIR}")" \
   --output "${CLAUDE_DIR}/dep-graph.toon" \
-  --languages typescript,javascript,python,go \
-  . 2>&1
+  2>&1
 
 if [ -f "${CLAUDE_DIR}/dep-graph.toon" ]; then
-  FILE_COUNT=$(grep -c "^FILE{" "${CLAUDE_DIR}/dep-graph.toon" || echo "0")
+  FILE_COUNT=$(grep -c "^FILE:" "${CLAUDE_DIR}/dep-graph.toon" || echo "0")
   echo ""
   echo "✓ Dependency graph built successfully"
   echo "  Files analyzed: ${FILE_COUNT}"
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultLanguages are loaded when detection finds nothing to go on
+var defaultLanguages = []string{"typescript", "javascript", "go", "python"}
+
+// maxDetectFiles bounds the detection walk on very large trees
+const maxDetectFiles = 20000
+
+// languageExtensions maps source extensions to the language they indicate.
+// Languages without a grammar are reported but not loaded.
+var languageExtensions = map[string]string{
+	".ts":   "typescript",
+	".mts":  "typescript",
+	".cts":  "typescript",
+	".tsx":  "tsx",
+	".js":   "javascript",
+	".jsx":  "javascript",
+	".mjs":  "javascript",
+	".cjs":  "javascript",
+	".go":   "go",
+	".py":   "python",
+	".pyi":  "python",
+	".rs":   "rust",
+	".rb":   "ruby",
+	".java": "java",
+	".kt":   "kotlin",
+	".sh":   "shell",
+}
+
+// languageManifests maps project manifests to the language they indicate
+var languageManifests = map[string]string{
+	"go.mod":           "go",
+	"go.work":          "go",
+	"package.json":     "javascript",
+	"tsconfig.json":    "typescript",
+	"jsconfig.json":    "javascript",
+	"pyproject.toml":   "python",
+	"setup.py":         "python",
+	"setup.cfg":        "python",
+	"requirements.txt": "python",
+	"Pipfile":          "python",
+	"Cargo.toml":       "rust",
+	"Gemfile":          "ruby",
+	"pom.xml":          "java",
+	"build.gradle":     "java",
+}
+
+// shebangLanguages maps interpreters named in a shebang to a language
+var shebangLanguages = map[string]string{
+	"python":  "python",
+	"node":    "javascript",
+	"deno":    "typescript",
+	"ts-node": "typescript",
+	"tsx":     "typescript",
+	"bun":     "typescript",
+	"sh":      "shell",
+	"bash":    "shell",
+	"zsh":     "shell",
+	"ruby":    "ruby",
+}
+
+// languageDetection is the outcome of sampling a project's contents
+type languageDetection struct {
+	Languages   []string            // Grammars to load, sorted
+	Unsupported []string            // Detected languages without a grammar, sorted
+	Evidence    map[string][]string // Language -> what indicated it
+}
+
+// detectLanguages samples file extensions, manifest files and the shebangs
+// of extensionless scripts below root to decide which grammars to load
+func detectLanguages(root string, skipDir func(dir string) bool) *languageDetection {
+	counts := make(map[string]int)
+	evidence := make(map[string][]string)
+	found := make(map[string]bool)
+	sampled := 0
+
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != root && skipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		sampled++
+		if sampled > maxDetectFiles {
+			return filepath.SkipAll
+		}
+
+		name := info.Name()
+		if lang, ok := languageManifests[name]; ok {
+			found[lang] = true
+			evidence[lang] = append(evidence[lang], relativeTo(root, path))
+		}
+
+		ext := filepath.Ext(name)
+		if lang, ok := languageExtensions[ext]; ok {
+			found[lang] = true
+			counts[lang]++
+			return nil
+		}
+
+		if ext == "" && info.Mode().IsRegular() {
+			if lang := shebangLanguage(path); lang != "" {
+				found[lang] = true
+				evidence[lang] = append(evidence[lang], "shebang in "+relativeTo(root, path))
+			}
+		}
+		return nil
+	})
+
+	// TSX sources are TypeScript projects too
+	if found["tsx"] {
+		found["typescript"] = true
+		evidence["typescript"] = append(evidence["typescript"], "TSX sources")
+	}
+
+	detection := &languageDetection{Evidence: make(map[string][]string)}
+	for lang := range found {
+		var reasons []string
+		switch counts[lang] {
+		case 0:
+		case 1:
+			reasons = append(reasons, "1 file")
+		default:
+			reasons = append(reasons, fmt.Sprintf("%d files", counts[lang]))
+		}
+		reasons = append(reasons, evidence[lang]...)
+		detection.Evidence[lang] = reasons
+
+		if _, ok := grammars[lang]; ok {
+			detection.Languages = append(detection.Languages, lang)
+		} else {
+			detection.Unsupported = append(detection.Unsupported, lang)
+		}
+	}
+	sort.Strings(detection.Languages)
+	sort.Strings(detection.Unsupported)
+
+	return detection
+}
+
+// fileLanguage returns the language of a source file: from its extension,
+// or from the shebang of an extensionless script
+func fileLanguage(path string) string {
+	ext := filepath.Ext(path)
+	if ext == "" {
+		return shebangLanguage(path)
+	}
+	return languageExtensions[ext]
+}
+
+// shebangLanguage reads the interpreter from a script's first line, such
+// as "#!/usr/bin/env python3" or "#!/bin/bash"
+func shebangLanguage(path string) string {
+	file, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	head := make([]byte, 128)
+	n, _ := file.Read(head)
+	return shebangContentLanguage(head[:n])
+}
+
+// shebangContentLanguage is shebangLanguage for content already read
+func shebangContentLanguage(head []byte) string {
+	if !bytes.HasPrefix(head, []byte("#!")) {
+		return ""
+	}
+	if i := bytes.IndexByte(head, '\n'); i >= 0 {
+		head = head[:i]
+	}
+
+	fields := strings.Fields(string(head[2:]))
+	if len(fields) == 0 {
+		return ""
+	}
+	interpreter := filepath.Base(fields[0])
+	if interpreter == "env" {
+		// Skip env options such as -S
+		interpreter = ""
+		for _, field := range fields[1:] {
+			if !strings.HasPrefix(field, "-") {
+				interpreter = field
+				break
+			}
+		}
+	}
+
+	// python3.12 -> python
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	return shebangLanguages[interpreter]
+}
+
+// describe formats the detection for verbose output
+func (d *languageDetection) describe(lang string) string {
+	return fmt.Sprintf("%s (%s)", lang, strings.Join(d.Evidence[lang], ", "))
+}
+
+func relativeTo(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
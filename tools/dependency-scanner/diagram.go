@@ -0,0 +1,300 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Ways of grouping files in DOT and Mermaid output
+const (
+	ClusterDir     = "dir"
+	ClusterPackage = "package"
+	ClusterNone    = "none"
+)
+
+// cycleColor highlights files and imports that take part in a cycle
+const cycleColor = "#d62728"
+
+// DiagramOptions selects and groups the files drawn by SaveDOT and
+// SaveMermaid
+type DiagramOptions struct {
+	Focus   []string // Only draw files within Hops imports of these files, in either direction (all if empty)
+	Hops    int
+	Cluster string // ClusterDir, ClusterPackage or ClusterNone
+}
+
+// diagram is the part of the graph to draw
+type diagram struct {
+	files    []string            // Sorted
+	ids      map[string]string   // Node ID of each file
+	clusters map[string][]string // Files by cluster label, if clustered
+	labels   []string            // Sorted cluster labels
+	edges    []diagramEdge
+	focus    map[string]bool
+	cyclic   map[string]bool // Files in a cycle
+}
+
+type diagramEdge struct {
+	from, to string
+	kind     string
+	cyclic   bool
+}
+
+// newDiagram selects the files within reach of the focus files and groups
+// them into clusters
+func (g *DependencyGraph) newDiagram(opts DiagramOptions) (*diagram, error) {
+	edges := importEdges(g)
+
+	d := &diagram{
+		ids:      make(map[string]string),
+		clusters: make(map[string][]string),
+		focus:    make(map[string]bool),
+		cyclic:   make(map[string]bool),
+	}
+
+	included := make(map[string]bool)
+	if len(opts.Focus) == 0 {
+		for path := range g.Files {
+			included[path] = true
+		}
+	} else {
+		for _, target := range opts.Focus {
+			path, err := g.findFile(target)
+			if err != nil {
+				return nil, err
+			}
+			d.focus[path] = true
+		}
+		included = g.neighborhood(d.focus, edges, opts.Hops)
+	}
+
+	for _, path := range g.SortedPaths() {
+		if included[path] {
+			d.ids[path] = fmt.Sprintf("n%d", len(d.files))
+			d.files = append(d.files, path)
+		}
+	}
+
+	// An import is part of a cycle when both files share a component
+	component := make(map[string]int)
+	for i, scc := range stronglyConnected(g, edges) {
+		for _, file := range scc {
+			component[file] = i + 1
+			d.cyclic[file] = true
+		}
+	}
+
+	for _, from := range d.files {
+		for _, edge := range edges[from] {
+			if !included[edge.to] {
+				continue
+			}
+			d.edges = append(d.edges, diagramEdge{
+				from:   from,
+				to:     edge.to,
+				kind:   edge.imp.Kind,
+				cyclic: component[from] != 0 && component[from] == component[edge.to],
+			})
+		}
+	}
+
+	if opts.Cluster != ClusterNone {
+		for _, path := range d.files {
+			label := g.relPath(filepath.Dir(path))
+			if node := g.Files[path]; opts.Cluster == ClusterPackage && node.Package != "" {
+				label = node.Package
+			}
+			if _, ok := d.clusters[label]; !ok {
+				d.labels = append(d.labels, label)
+			}
+			d.clusters[label] = append(d.clusters[label], path)
+		}
+		sort.Strings(d.labels)
+	}
+
+	return d, nil
+}
+
+// neighborhood returns the files within hops imports of the given files,
+// following imports and importers alike
+func (g *DependencyGraph) neighborhood(start map[string]bool, edges map[string][]importEdge, hops int) map[string]bool {
+	neighbors := make(map[string][]string, len(edges))
+	for from, list := range edges {
+		for _, edge := range list {
+			neighbors[from] = append(neighbors[from], edge.to)
+			neighbors[edge.to] = append(neighbors[edge.to], from)
+		}
+	}
+
+	distance := make(map[string]int, len(start))
+	var queue []string
+	for path := range start {
+		distance[path] = 0
+		queue = append(queue, path)
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if distance[current] >= hops {
+			continue
+		}
+		for _, next := range neighbors[current] {
+			if _, seen := distance[next]; !seen {
+				distance[next] = distance[current] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	included := make(map[string]bool, len(distance))
+	for path := range distance {
+		included[path] = true
+	}
+	return included
+}
+
+// nodeLabel names a file by its base name inside a cluster, else by its
+// path relative to the root
+func (d *diagram) nodeLabel(g *DependencyGraph, path string) string {
+	if len(d.labels) > 0 {
+		return filepath.Base(path)
+	}
+	return g.relPath(path)
+}
+
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// SaveDOT writes the graph in Graphviz DOT format. Files in a cycle and
+// the imports between them are drawn in red, focus files are filled and
+// type-only or dynamic imports are dashed or dotted.
+func (g *DependencyGraph) SaveDOT(outputPath string, opts DiagramOptions) error {
+	d, err := g.newDiagram(opts)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("digraph dependencies {\n")
+	b.WriteString("  rankdir=LR;\n")
+	b.WriteString("  node [shape=box, style=rounded, fontname=\"Helvetica\", fontsize=10];\n")
+	b.WriteString("  edge [color=\"#666666\", arrowsize=0.7];\n")
+
+	writeNode := func(indent, path string) {
+		attrs := []string{fmt.Sprintf("label=\"%s\"", dotEscaper.Replace(d.nodeLabel(g, path)))}
+		if d.cyclic[path] {
+			attrs = append(attrs, fmt.Sprintf("color=\"%s\"", cycleColor), "penwidth=2")
+		}
+		if d.focus[path] {
+			attrs = append(attrs, "style=\"rounded,filled\"", "fillcolor=\"#fff2a8\"")
+		}
+		fmt.Fprintf(&b, "%s%s [%s];\n", indent, d.ids[path], strings.Join(attrs, ", "))
+	}
+
+	if len(d.labels) > 0 {
+		for i, label := range d.labels {
+			fmt.Fprintf(&b, "\n  subgraph cluster_%d {\n", i)
+			fmt.Fprintf(&b, "    label=\"%s\";\n", dotEscaper.Replace(label))
+			b.WriteString("    style=rounded;\n    color=\"#bbbbbb\";\n")
+			for _, path := range d.clusters[label] {
+				writeNode("    ", path)
+			}
+			b.WriteString("  }\n")
+		}
+	} else {
+		b.WriteString("\n")
+		for _, path := range d.files {
+			writeNode("  ", path)
+		}
+	}
+
+	b.WriteString("\n")
+	for _, edge := range d.edges {
+		var attrs []string
+		switch edge.kind {
+		case ImportTypeOnly:
+			attrs = append(attrs, "style=dashed")
+		case ImportDynamic:
+			attrs = append(attrs, "style=dotted")
+		}
+		if edge.cyclic {
+			attrs = append(attrs, fmt.Sprintf("color=\"%s\"", cycleColor), "penwidth=2")
+		}
+		fmt.Fprintf(&b, "  %s -> %s", d.ids[edge.from], d.ids[edge.to])
+		if len(attrs) > 0 {
+			fmt.Fprintf(&b, " [%s]", strings.Join(attrs, ", "))
+		}
+		b.WriteString(";\n")
+	}
+	b.WriteString("}\n")
+
+	return writeFileAtomic(outputPath, []byte(b.String()))
+}
+
+var mermaidEscaper = strings.NewReplacer(`"`, "#quot;", "\n", " ")
+
+// SaveMermaid writes the graph as a Mermaid flowchart, highlighting cycles
+// and focus files like SaveDOT
+func (g *DependencyGraph) SaveMermaid(outputPath string, opts DiagramOptions) error {
+	d, err := g.newDiagram(opts)
+	if err != nil {
+		return err
+	}
+
+	var b strings.Builder
+	b.WriteString("flowchart LR\n")
+
+	writeNode := func(indent, path string) {
+		fmt.Fprintf(&b, "%s%s[\"%s\"]\n", indent, d.ids[path], mermaidEscaper.Replace(d.nodeLabel(g, path)))
+	}
+
+	if len(d.labels) > 0 {
+		for i, label := range d.labels {
+			fmt.Fprintf(&b, "  subgraph c%d[\"%s\"]\n", i, mermaidEscaper.Replace(label))
+			for _, path := range d.clusters[label] {
+				writeNode("    ", path)
+			}
+			b.WriteString("  end\n")
+		}
+	} else {
+		for _, path := range d.files {
+			writeNode("  ", path)
+		}
+	}
+
+	var cyclicLinks []string
+	for i, edge := range d.edges {
+		arrow := "-->"
+		if edge.kind == ImportTypeOnly || edge.kind == ImportDynamic {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", d.ids[edge.from], arrow, d.ids[edge.to])
+		if edge.cyclic {
+			cyclicLinks = append(cyclicLinks, fmt.Sprint(i))
+		}
+	}
+
+	var cyclic, focus []string
+	for _, path := range d.files {
+		if d.cyclic[path] {
+			cyclic = append(cyclic, d.ids[path])
+		}
+		if d.focus[path] {
+			focus = append(focus, d.ids[path])
+		}
+	}
+	if len(cyclic) > 0 {
+		fmt.Fprintf(&b, "  classDef cycle stroke:%s,stroke-width:2px\n", cycleColor)
+		fmt.Fprintf(&b, "  class %s cycle\n", strings.Join(cyclic, ","))
+	}
+	if len(cyclicLinks) > 0 {
+		fmt.Fprintf(&b, "  linkStyle %s stroke:%s,stroke-width:2px\n", strings.Join(cyclicLinks, ","), cycleColor)
+	}
+	if len(focus) > 0 {
+		b.WriteString("  classDef focus fill:#fff2a8\n")
+		fmt.Fprintf(&b, "  class %s focus\n", strings.Join(focus, ","))
+	}
+
+	return writeFileAtomic(outputPath, []byte(b.String()))
+}
@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// Entry point reasons stored in FileNode.EntryPoint
+const (
+	EntryGoMain          = "Go package main"
+	EntryPythonMainBlock = "Python __main__ block"
+	EntryPythonMainFile  = "Python __main__.py"
+	EntryShebang         = "shebang script"
+	EntryPackageMain     = "package.json main"
+	EntryPackageModule   = "package.json module"
+	EntryPackageBin      = "package.json bin"
+	EntryPackageExports  = "package.json exports"
+	EntryConsoleScript   = "console script"
+	EntryTest            = "test file"
+	EntryToolConfig      = "tool config file"
+	EntryConfigured      = "configured entry point"
+)
+
+// buildOutputDirs are package.json target directories whose sources
+// usually live under src/
+var buildOutputDirs = []string{"dist", "build", "lib", "out"}
+
+// pythonScriptEntry matches `name = "module.path:function"` in a scripts
+// table and `name = module.path:function` in setup.cfg or setup.py
+var pythonScriptEntry = regexp.MustCompile(`^\s*["']?([\w.-]+)["']?\s*=\s*["']?([\w.]+)\s*:\s*[\w.]+`)
+
+// detectEntryPoint recognises files that run on their own from their
+// source: Go files of package main, Python modules with a __main__ block
+// or named __main__.py, and scripts starting with a shebang
+func (p *Parser) detectEntryPoint(root *sitter.Node, content []byte, lang, filePath string) string {
+	switch lang {
+	case "go":
+		for i := 0; i < int(root.NamedChildCount()); i++ {
+			clause := root.NamedChild(i)
+			if clause.Type() != "package_clause" {
+				continue
+			}
+			for j := 0; j < int(clause.NamedChildCount()); j++ {
+				if nodeText(clause.NamedChild(j), content) == "main" {
+					return EntryGoMain
+				}
+			}
+			return ""
+		}
+	case "python":
+		if filepath.Base(filePath) == "__main__.py" {
+			return EntryPythonMainFile
+		}
+		for i := 0; i < int(root.NamedChildCount()); i++ {
+			stmt := root.NamedChild(i)
+			if stmt.Type() != "if_statement" {
+				continue
+			}
+			condition := strings.Join(strings.Fields(nodeText(stmt.ChildByFieldName("condition"), content)), "")
+			if strings.Contains(condition, "__name__==") && strings.Contains(condition, "__main__") {
+				return EntryPythonMainBlock
+			}
+		}
+	}
+	if bytes.HasPrefix(content, []byte("#!")) {
+		return EntryShebang
+	}
+	return ""
+}
+
+// markEntryPoints records why each file runs without being imported.
+// Entry points found in the source are kept; configured entry points take
+// precedence, followed by package.json targets, Python console scripts,
+// test files and tool configuration files.
+func (s *Scanner) markEntryPoints() {
+	mark := func(path, reason string) {
+		if node, ok := s.graph.Files[path]; ok && node.EntryPoint == "" {
+			node.EntryPoint = reason
+		}
+	}
+
+	for path, node := range s.graph.Files {
+		if s.isEntryPoint(path) {
+			node.EntryPoint = EntryConfigured
+		}
+	}
+
+	for _, dir := range s.projectDirs() {
+		for path, reason := range packageEntryPoints(dir) {
+			mark(path, reason)
+		}
+		for path, reason := range pythonScriptEntryPoints(dir) {
+			mark(path, reason)
+		}
+	}
+
+	for path := range s.graph.Files {
+		rel := s.graph.relPath(path)
+		switch {
+		case isTestFile(rel):
+			mark(path, EntryTest)
+		case isToolConfigFile(rel):
+			mark(path, EntryToolConfig)
+		}
+	}
+}
+
+// projectDirs returns the directories, from the root down to those of the
+// scanned files, that hold a package.json or Python project manifest
+func (s *Scanner) projectDirs() []string {
+	seen := make(map[string]bool)
+	var dirs []string
+	for path := range s.graph.Files {
+		for dir := filepath.Dir(path); !seen[dir]; dir = filepath.Dir(dir) {
+			seen[dir] = true
+			for _, manifest := range []string{"package.json", "pyproject.toml", "setup.cfg", "setup.py"} {
+				if fileExists(filepath.Join(dir, manifest)) {
+					dirs = append(dirs, dir)
+					break
+				}
+			}
+			if dir == s.rootPath || dir == filepath.Dir(dir) {
+				break
+			}
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
+
+// packageEntryPoints maps the files named by the main, module, bin and
+// exports fields of dir/package.json to the field naming them
+func packageEntryPoints(dir string) map[string]string {
+	content, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var pkg packageJSON
+	if json.Unmarshal(content, &pkg) != nil {
+		return nil
+	}
+
+	entries := make(map[string]string)
+	add := func(target, reason string) {
+		if file := resolvePackageTarget(dir, target); file != "" {
+			if _, ok := entries[file]; !ok {
+				entries[file] = reason
+			}
+		}
+	}
+
+	add(pkg.Main, EntryPackageMain)
+	add(pkg.Module, EntryPackageModule)
+
+	// "bin": "./cli.js" or "bin": {"name": "./cli.js"}
+	var bin string
+	var bins map[string]string
+	if json.Unmarshal(pkg.Bin, &bin) == nil {
+		add(bin, EntryPackageBin)
+	} else if json.Unmarshal(pkg.Bin, &bins) == nil {
+		for _, target := range bins {
+			add(target, EntryPackageBin)
+		}
+	}
+
+	if len(pkg.Exports) > 0 && string(pkg.Exports) != "null" {
+		var subpaths map[string]json.RawMessage
+		if json.Unmarshal(pkg.Exports, &subpaths) == nil && hasSubpathKeys(subpaths) {
+			for _, value := range subpaths {
+				for _, target := range exportTargets(value) {
+					add(target, EntryPackageExports)
+				}
+			}
+		} else {
+			for _, target := range exportTargets(pkg.Exports) {
+				add(target, EntryPackageExports)
+			}
+		}
+	}
+
+	return entries
+}
+
+// resolvePackageTarget maps a package.json target to a source file,
+// looking under src/ for targets inside build output directories
+func resolvePackageTarget(dir, target string) string {
+	if target == "" || strings.Contains(target, "*") {
+		return ""
+	}
+	target = strings.TrimPrefix(filepath.ToSlash(target), "./")
+	if file := resolveFile(filepath.Join(dir, filepath.FromSlash(target))); file != "" {
+		return file
+	}
+
+	first, rest, ok := strings.Cut(target, "/")
+	if !ok {
+		return ""
+	}
+	for _, out := range buildOutputDirs {
+		if first == out {
+			source := strings.TrimSuffix(rest, ".d.ts")
+			return resolveFile(filepath.Join(dir, "src", filepath.FromSlash(source)))
+		}
+	}
+	return ""
+}
+
+// pythonScriptEntryPoints maps the modules of the console and GUI scripts
+// declared in dir's pyproject.toml, setup.cfg or setup.py to their files
+func pythonScriptEntryPoints(dir string) map[string]string {
+	var modules []string
+	modules = append(modules, readScriptModules(filepath.Join(dir, "pyproject.toml"), func(section string) bool {
+		switch section {
+		case "project.scripts", "project.gui-scripts", "tool.poetry.scripts":
+			return true
+		}
+		return false
+	})...)
+	modules = append(modules, readScriptModules(filepath.Join(dir, "setup.cfg"), func(section string) bool {
+		return section == "options.entry_points"
+	})...)
+	if content, err := os.ReadFile(filepath.Join(dir, "setup.py")); err == nil && strings.Contains(string(content), "_scripts") {
+		for _, value := range quotedString.FindAllStringSubmatch(string(content), -1) {
+			if match := pythonScriptEntry.FindStringSubmatch(value[1]); match != nil {
+				modules = append(modules, match[2])
+			}
+		}
+	}
+
+	entries := make(map[string]string)
+	for _, module := range modules {
+		for _, root := range pythonProjectRoots(dir) {
+			file := pythonModuleFile(filepath.Join(root, filepath.FromSlash(strings.ReplaceAll(module, ".", "/"))))
+			if file != "" {
+				entries[file] = EntryConsoleScript
+				break
+			}
+		}
+	}
+	return entries
+}
+
+// readScriptModules returns the modules of `name = module:function`
+// entries in the INI or TOML sections accepted by inSection
+func readScriptModules(path string, inSection func(section string) bool) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	var modules []string
+	active := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			active = inSection(strings.Trim(trimmed, "[] "))
+			continue
+		}
+		if !active || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if match := pythonScriptEntry.FindStringSubmatch(line); match != nil {
+			modules = append(modules, match[2])
+		}
+	}
+	return modules
+}
+
+// isToolConfigFile recognises configuration files that build and test
+// tools load by name, such as vite.config.ts or setup.py
+func isToolConfigFile(rel string) bool {
+	name := filepath.Base(rel)
+	return strings.Contains(name, ".config.") || name == "setup.py" || name == "noxfile.py"
+}
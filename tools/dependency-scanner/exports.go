@@ -0,0 +1,506 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// nodeText returns the source text covered by a node
+func nodeText(n *sitter.Node, content []byte) string {
+	if n == nil {
+		return ""
+	}
+	return string(content[n.StartByte():n.EndByte()])
+}
+
+// exportSet collects exports in declaration order, keyed by name and kind
+// so that TS overloads or repeated Go receivers don't produce duplicates
+type exportSet struct {
+	exports []Export
+	index   map[string]int
+}
+
+func newExportSet() *exportSet {
+	return &exportSet{index: make(map[string]int)}
+}
+
+func (s *exportSet) add(exp Export) {
+	key := exp.Name + "\x00" + exp.Type
+	if i, exists := s.index[key]; exists {
+		// A later exported declaration upgrades an earlier private one
+		if exp.IsPublic() {
+			s.exports[i].Visibility = VisibilityPublic
+		}
+		s.exports[i].IsDefault = s.exports[i].IsDefault || exp.IsDefault
+		return
+	}
+	s.index[key] = len(s.exports)
+	s.exports = append(s.exports, exp)
+}
+
+// find returns the first export with the given name, regardless of kind
+func (s *exportSet) find(name string) *Export {
+	for i := range s.exports {
+		if s.exports[i].Name == name {
+			return &s.exports[i]
+		}
+	}
+	return nil
+}
+
+func (s *exportSet) list() []Export {
+	if s.exports == nil {
+		return []Export{}
+	}
+	return s.exports
+}
+
+// extractJSExports extracts top-level JavaScript/TypeScript declarations.
+// Declarations behind the export keyword are public, everything else is
+// private to the module.
+func (p *Parser) extractJSExports(root *sitter.Node, content []byte) []Export {
+	set := newExportSet()
+
+	// Local names referenced by `export { a as b }` or `export default a`
+	type reference struct {
+		local     string
+		exported  string
+		isDefault bool
+		line      int
+	}
+	var references []reference
+
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		child := root.NamedChild(i)
+		line := int(child.StartPoint().Row) + 1
+
+		if child.Type() != "export_statement" {
+			for _, exp := range jsDeclarationExports(child, content) {
+				exp.Visibility = VisibilityPrivate
+				set.add(exp)
+			}
+			continue
+		}
+
+		// Re-exports (`export ... from "x"`) don't declare local symbols
+		if child.ChildByFieldName("source") != nil {
+			continue
+		}
+
+		isDefault := false
+		for j := 0; j < int(child.ChildCount()); j++ {
+			if child.Child(j).Type() == "default" {
+				isDefault = true
+				break
+			}
+		}
+
+		if decl := child.ChildByFieldName("declaration"); decl != nil {
+			for _, exp := range jsDeclarationExports(decl, content) {
+				exp.Visibility = VisibilityPublic
+				exp.IsDefault = isDefault
+				set.add(exp)
+			}
+			continue
+		}
+
+		if value := child.ChildByFieldName("value"); value != nil {
+			if value.Type() == "identifier" {
+				references = append(references, reference{
+					local:     nodeText(value, content),
+					exported:  "default",
+					isDefault: true,
+					line:      line,
+				})
+				continue
+			}
+			set.add(Export{
+				Name:       "default",
+				Type:       jsValueKind(value),
+				Visibility: VisibilityPublic,
+				IsDefault:  true,
+				Line:       line,
+			})
+			continue
+		}
+
+		// export { a, b as c }
+		for j := 0; j < int(child.NamedChildCount()); j++ {
+			clause := child.NamedChild(j)
+			if clause.Type() != "export_clause" {
+				continue
+			}
+			for k := 0; k < int(clause.NamedChildCount()); k++ {
+				spec := clause.NamedChild(k)
+				if spec.Type() != "export_specifier" {
+					continue
+				}
+				local := nodeText(spec.ChildByFieldName("name"), content)
+				exported := local
+				if alias := spec.ChildByFieldName("alias"); alias != nil {
+					exported = nodeText(alias, content)
+				}
+				references = append(references, reference{
+					local:     local,
+					exported:  exported,
+					isDefault: exported == "default",
+					line:      line,
+				})
+			}
+		}
+	}
+
+	// Resolve references after all declarations are known, since
+	// `export { a }` may appear before `function a() {}`
+	for _, ref := range references {
+		decl := set.find(ref.local)
+		if decl != nil && (ref.exported == ref.local || ref.isDefault) {
+			decl.Visibility = VisibilityPublic
+			decl.IsDefault = decl.IsDefault || ref.isDefault
+			continue
+		}
+
+		kind := ExportVariable
+		if decl != nil {
+			kind = decl.Type
+		}
+		set.add(Export{
+			Name:       ref.exported,
+			Type:       kind,
+			Visibility: VisibilityPublic,
+			IsDefault:  ref.isDefault,
+			Line:       ref.line,
+		})
+	}
+
+	return set.list()
+}
+
+// jsDeclarationExports returns the symbols introduced by a JS/TS declaration
+func jsDeclarationExports(decl *sitter.Node, content []byte) []Export {
+	line := int(decl.StartPoint().Row) + 1
+	named := func(kind string) []Export {
+		name := decl.ChildByFieldName("name")
+		if name == nil {
+			// Anonymous `export default class {}`
+			return []Export{{Name: "default", Type: kind, Line: line}}
+		}
+		return []Export{{Name: nodeText(name, content), Type: kind, Line: line}}
+	}
+
+	switch decl.Type() {
+	case "function_declaration", "generator_function_declaration", "function_signature":
+		return named(ExportFunction)
+	case "class_declaration", "abstract_class_declaration", "class":
+		return named(ExportClass)
+	case "interface_declaration":
+		return named(ExportInterface)
+	case "type_alias_declaration":
+		return named(ExportType)
+	case "enum_declaration":
+		return named(ExportEnum)
+	case "internal_module", "module":
+		return named(ExportNamespace)
+	case "ambient_declaration":
+		// declare function f(): void;
+		var exports []Export
+		for i := 0; i < int(decl.NamedChildCount()); i++ {
+			exports = append(exports, jsDeclarationExports(decl.NamedChild(i), content)...)
+		}
+		return exports
+	case "lexical_declaration", "variable_declaration":
+		var exports []Export
+		for i := 0; i < int(decl.NamedChildCount()); i++ {
+			declarator := decl.NamedChild(i)
+			if declarator.Type() != "variable_declarator" {
+				continue
+			}
+			name := declarator.ChildByFieldName("name")
+			// Destructuring patterns don't have a single name
+			if name == nil || name.Type() != "identifier" {
+				continue
+			}
+			kind := ExportVariable
+			if value := declarator.ChildByFieldName("value"); value != nil {
+				kind = jsValueKind(value)
+			}
+			exports = append(exports, Export{
+				Name: nodeText(name, content),
+				Type: kind,
+				Line: int(declarator.StartPoint().Row) + 1,
+			})
+		}
+		return exports
+	}
+
+	return nil
+}
+
+// jsValueKind classifies an expression bound to an exported name
+func jsValueKind(value *sitter.Node) string {
+	switch value.Type() {
+	case "arrow_function", "function_expression", "function", "generator_function":
+		return ExportFunction
+	case "class":
+		return ExportClass
+	default:
+		return ExportVariable
+	}
+}
+
+// extractGoExports extracts top-level Go declarations. Capitalized names are
+// public, everything else is shared with the rest of the package only.
+func (p *Parser) extractGoExports(root *sitter.Node, content []byte) []Export {
+	set := newExportSet()
+
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		child := root.NamedChild(i)
+		line := int(child.StartPoint().Row) + 1
+
+		switch child.Type() {
+		case "function_declaration":
+			name := nodeText(child.ChildByFieldName("name"), content)
+			set.add(Export{
+				Name:       name,
+				Type:       ExportFunction,
+				Visibility: goVisibility(name),
+				Line:       line,
+			})
+
+		case "method_declaration":
+			name := nodeText(child.ChildByFieldName("name"), content)
+			receiver := goReceiverType(child.ChildByFieldName("receiver"), content)
+			visibility := goVisibility(name)
+			// Methods on unexported types aren't reachable by name from outside
+			if receiver != "" && goVisibility(receiver) != VisibilityPublic {
+				visibility = VisibilityPackage
+			}
+			if receiver != "" {
+				name = receiver + "." + name
+			}
+			set.add(Export{
+				Name:       name,
+				Type:       ExportMethod,
+				Visibility: visibility,
+				Line:       line,
+			})
+
+		case "type_declaration":
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				spec := child.NamedChild(j)
+				if spec.Type() != "type_spec" && spec.Type() != "type_alias" {
+					continue
+				}
+				name := nodeText(spec.ChildByFieldName("name"), content)
+				kind := ExportType
+				if t := spec.ChildByFieldName("type"); t != nil && spec.Type() == "type_spec" {
+					switch t.Type() {
+					case "struct_type":
+						kind = ExportClass
+					case "interface_type":
+						kind = ExportInterface
+					}
+				}
+				set.add(Export{
+					Name:       name,
+					Type:       kind,
+					Visibility: goVisibility(name),
+					Line:       int(spec.StartPoint().Row) + 1,
+				})
+			}
+
+		case "const_declaration", "var_declaration":
+			var walkSpecs func(*sitter.Node)
+			walkSpecs = func(n *sitter.Node) {
+				for j := 0; j < int(n.NamedChildCount()); j++ {
+					spec := n.NamedChild(j)
+					switch spec.Type() {
+					case "const_spec", "var_spec":
+						for k := 0; k < int(spec.NamedChildCount()); k++ {
+							ident := spec.NamedChild(k)
+							if ident.Type() != "identifier" {
+								continue
+							}
+							name := nodeText(ident, content)
+							if name == "_" {
+								continue
+							}
+							set.add(Export{
+								Name:       name,
+								Type:       ExportVariable,
+								Visibility: goVisibility(name),
+								Line:       int(spec.StartPoint().Row) + 1,
+							})
+						}
+					case "var_spec_list":
+						walkSpecs(spec)
+					}
+				}
+			}
+			walkSpecs(child)
+		}
+	}
+
+	return set.list()
+}
+
+// goVisibility applies Go's capitalization rule
+func goVisibility(name string) string {
+	r, _ := utf8.DecodeRuneInString(name)
+	if unicode.IsUpper(r) {
+		return VisibilityPublic
+	}
+	return VisibilityPackage
+}
+
+// goReceiverType returns the base type name of a method receiver,
+// stripping pointers and type parameters: (s *Stack[T]) -> Stack
+func goReceiverType(receiver *sitter.Node, content []byte) string {
+	if receiver == nil {
+		return ""
+	}
+	for i := 0; i < int(receiver.NamedChildCount()); i++ {
+		param := receiver.NamedChild(i)
+		if param.Type() != "parameter_declaration" {
+			continue
+		}
+		t := param.ChildByFieldName("type")
+		for t != nil {
+			switch t.Type() {
+			case "pointer_type":
+				t = t.NamedChild(0)
+			case "generic_type":
+				t = t.ChildByFieldName("type")
+			case "type_identifier":
+				return nodeText(t, content)
+			default:
+				return ""
+			}
+		}
+	}
+	return ""
+}
+
+// extractPythonExports extracts module-level Python definitions. When the
+// module defines __all__ it is authoritative; otherwise names starting with
+// an underscore are private.
+func (p *Parser) extractPythonExports(root *sitter.Node, content []byte) []Export {
+	set := newExportSet()
+	var all map[string]bool
+
+	for i := 0; i < int(root.NamedChildCount()); i++ {
+		child := root.NamedChild(i)
+		line := int(child.StartPoint().Row) + 1
+
+		def := child
+		if def.Type() == "decorated_definition" {
+			if inner := def.ChildByFieldName("definition"); inner != nil {
+				def = inner
+			}
+		}
+
+		switch def.Type() {
+		case "function_definition":
+			set.add(Export{
+				Name: nodeText(def.ChildByFieldName("name"), content),
+				Type: ExportFunction,
+				Line: line,
+			})
+
+		case "class_definition":
+			kind := ExportClass
+			if pythonIsEnum(def.ChildByFieldName("superclasses"), content) {
+				kind = ExportEnum
+			}
+			set.add(Export{
+				Name: nodeText(def.ChildByFieldName("name"), content),
+				Type: kind,
+				Line: line,
+			})
+
+		case "expression_statement":
+			for j := 0; j < int(def.NamedChildCount()); j++ {
+				stmt := def.NamedChild(j)
+				if stmt.Type() != "assignment" && stmt.Type() != "augmented_assignment" {
+					continue
+				}
+				left := stmt.ChildByFieldName("left")
+				if left == nil || left.Type() != "identifier" {
+					continue
+				}
+				name := nodeText(left, content)
+				if name == "__all__" {
+					if all == nil {
+						all = make(map[string]bool)
+					}
+					for _, n := range pythonStringList(stmt.ChildByFieldName("right"), content) {
+						all[n] = true
+					}
+					continue
+				}
+				if stmt.Type() == "assignment" {
+					set.add(Export{
+						Name: name,
+						Type: ExportVariable,
+						Line: line,
+					})
+				}
+			}
+		}
+	}
+
+	for i := range set.exports {
+		exp := &set.exports[i]
+		switch {
+		case all != nil && all[exp.Name]:
+			exp.Visibility = VisibilityPublic
+		case all != nil:
+			exp.Visibility = VisibilityPrivate
+		case strings.HasPrefix(exp.Name, "_"):
+			exp.Visibility = VisibilityPrivate
+		default:
+			exp.Visibility = VisibilityPublic
+		}
+	}
+
+	return set.list()
+}
+
+// pythonIsEnum reports whether a class derives from one of the enum bases
+func pythonIsEnum(superclasses *sitter.Node, content []byte) bool {
+	if superclasses == nil {
+		return false
+	}
+	for i := 0; i < int(superclasses.NamedChildCount()); i++ {
+		base := nodeText(superclasses.NamedChild(i), content)
+		base = base[strings.LastIndex(base, ".")+1:]
+		switch base {
+		case "Enum", "IntEnum", "StrEnum", "Flag", "IntFlag":
+			return true
+		}
+	}
+	return false
+}
+
+// pythonStringList returns the string literals of a list or tuple literal
+func pythonStringList(n *sitter.Node, content []byte) []string {
+	if n == nil {
+		return nil
+	}
+	var values []string
+	for i := 0; i < int(n.NamedChildCount()); i++ {
+		item := n.NamedChild(i)
+		if item.Type() != "string" {
+			continue
+		}
+		for j := 0; j < int(item.NamedChildCount()); j++ {
+			if part := item.NamedChild(j); part.Type() == "string_content" {
+				values = append(values, nodeText(part, content))
+			}
+		}
+	}
+	return values
+}
@@ -0,0 +1,253 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// goModule is a Go module found in the scanned tree or referenced by go.work
+type goModule struct {
+	Path     string            // module path from the module directive
+	Dir      string            // directory containing go.mod
+	Replaces map[string]string // module path -> local directory, from replace directives
+}
+
+// goModuleSet indexes all Go modules of a (possibly multi-module) repository
+type goModuleSet struct {
+	modules      []*goModule       // sorted by descending directory length
+	byPath       map[string]string // module path -> directory
+	workReplaces map[string]string // replace directives from go.work
+}
+
+// discoverGoModules finds every go.mod under rootPath plus the modules
+// listed in a go.work `use` directive, which may live outside the tree
+func discoverGoModules(rootPath string, skipDir func(dir string) bool) *goModuleSet {
+	set := &goModuleSet{
+		byPath:       make(map[string]string),
+		workReplaces: make(map[string]string),
+	}
+	seen := make(map[string]bool)
+
+	addModule := func(dir string) {
+		dir = filepath.Clean(dir)
+		if seen[dir] {
+			return
+		}
+		seen[dir] = true
+		if mod := readGoMod(dir); mod != nil {
+			set.modules = append(set.modules, mod)
+			set.byPath[mod.Path] = mod.Dir
+		}
+	}
+
+	if content, err := os.ReadFile(filepath.Join(rootPath, "go.work")); err == nil {
+		for _, directive := range parseGoModDirectives(string(content)) {
+			switch directive[0] {
+			case "use":
+				if len(directive) >= 2 {
+					addModule(filepath.Join(rootPath, directive[1]))
+				}
+			case "replace":
+				if from, to, ok := localReplace(directive); ok {
+					set.workReplaces[from] = filepath.Join(rootPath, to)
+				}
+			}
+		}
+	}
+
+	filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != rootPath && skipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if info.Name() == "go.mod" {
+			addModule(filepath.Dir(path))
+		}
+		return nil
+	})
+
+	if len(set.modules) == 0 {
+		return nil
+	}
+
+	sort.Slice(set.modules, func(i, j int) bool {
+		return len(set.modules[i].Dir) > len(set.modules[j].Dir)
+	})
+
+	return set
+}
+
+// readGoMod parses the module path and local replace directives of dir/go.mod
+func readGoMod(dir string) *goModule {
+	content, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return nil
+	}
+
+	mod := &goModule{Dir: dir, Replaces: make(map[string]string)}
+	for _, directive := range parseGoModDirectives(string(content)) {
+		switch directive[0] {
+		case "module":
+			if len(directive) >= 2 {
+				mod.Path = directive[1]
+			}
+		case "replace":
+			if from, to, ok := localReplace(directive); ok {
+				mod.Replaces[from] = filepath.Join(dir, to)
+			}
+		}
+	}
+
+	if mod.Path == "" {
+		return nil
+	}
+	return mod
+}
+
+// parseGoModDirectives splits go.mod/go.work content into directives,
+// expanding blocks such as `replace ( ... )` into one directive per line.
+// Each directive is returned as its verb followed by unquoted fields.
+func parseGoModDirectives(content string) [][]string {
+	var directives [][]string
+	block := ""
+
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		for i := range fields {
+			fields[i] = strings.Trim(fields[i], "\"`")
+		}
+
+		if block != "" {
+			if fields[0] == ")" {
+				block = ""
+				continue
+			}
+			directives = append(directives, append([]string{block}, fields...))
+			continue
+		}
+
+		if len(fields) == 2 && fields[1] == "(" {
+			block = fields[0]
+			continue
+		}
+		directives = append(directives, fields)
+	}
+
+	return directives
+}
+
+// localReplace extracts `replace from [version] => ./local/dir`; replacements
+// pointing at another module version rather than a directory are ignored
+func localReplace(directive []string) (string, string, bool) {
+	arrow := -1
+	for i, field := range directive {
+		if field == "=>" {
+			arrow = i
+			break
+		}
+	}
+	if arrow < 2 || arrow+1 >= len(directive) {
+		return "", "", false
+	}
+
+	to := directive[arrow+1]
+	if !strings.HasPrefix(to, "./") && !strings.HasPrefix(to, "../") && !filepath.IsAbs(to) {
+		return "", "", false
+	}
+	return directive[1], to, true
+}
+
+// moduleFor returns the innermost module containing path: the one whose
+// directory is the longest prefix of path. A module at the scan root "."
+// contains every relative path inside the tree.
+func (m *goModuleSet) moduleFor(path string) *goModule {
+	var best *goModule
+	bestLen := -1
+	for _, mod := range m.modules {
+		dir := mod.Dir
+		if dir == "." {
+			dir = ""
+		}
+		if len(dir) > bestLen && dirContains(dir, path) {
+			best, bestLen = mod, len(dir)
+		}
+	}
+	return best
+}
+
+// dirContains reports whether path is dir or below it, where an empty dir
+// stands for the relative scan root
+func dirContains(dir, path string) bool {
+	sep := string(filepath.Separator)
+	if dir == "" {
+		return !filepath.IsAbs(path) && path != ".." && !strings.HasPrefix(path, ".."+sep)
+	}
+	return path == dir || strings.HasPrefix(path, dir+sep)
+}
+
+// packageDir maps an import path to a local directory, honoring the
+// importing module's replace directives, then go.work, then the modules
+// found in the tree. Returns "" for packages outside the repository.
+func (m *goModuleSet) packageDir(fromFile, importPath string) string {
+	var tables []map[string]string
+	if mod := m.moduleFor(fromFile); mod != nil {
+		tables = append(tables, mod.Replaces)
+	}
+	tables = append(tables, m.workReplaces, m.byPath)
+
+	for _, table := range tables {
+		if dir := longestModulePrefix(table, importPath); dir != "" {
+			return dir
+		}
+	}
+	return ""
+}
+
+// longestModulePrefix finds the module path in table that is the longest
+// prefix of importPath and returns the corresponding package directory
+func longestModulePrefix(table map[string]string, importPath string) string {
+	best := ""
+	for modulePath := range table {
+		if importPath != modulePath && !strings.HasPrefix(importPath, modulePath+"/") {
+			continue
+		}
+		if len(modulePath) > len(best) {
+			best = modulePath
+		}
+	}
+	if best == "" {
+		return ""
+	}
+
+	rel := strings.TrimPrefix(strings.TrimPrefix(importPath, best), "/")
+	return filepath.Join(table[best], filepath.FromSlash(rel))
+}
+
+// importPath returns the Go import path of the package in dir
+func (m *goModuleSet) importPath(dir string) string {
+	mod := m.moduleFor(dir)
+	if mod == nil {
+		return ""
+	}
+	rel, err := filepath.Rel(mod.Dir, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	if rel == "." {
+		return mod.Path
+	}
+	return mod.Path + "/" + filepath.ToSlash(rel)
+}
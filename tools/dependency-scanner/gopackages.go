@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bufio"
+	"go/build/constraint"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// goPackageIndex caches the buildable, non-test source files of each Go
+// package directory. An import of a package depends on all of them.
+type goPackageIndex struct {
+	files map[string][]string
+}
+
+func newGoPackageIndex() *goPackageIndex {
+	return &goPackageIndex{files: make(map[string][]string)}
+}
+
+// packageFiles returns the sorted non-test .go files of a package directory
+// that can be built on at least one platform
+func (g *goPackageIndex) packageFiles(dir string) []string {
+	if files, cached := g.files[dir]; cached {
+		return files
+	}
+
+	var files []string
+	matches, _ := filepath.Glob(filepath.Join(dir, "*.go"))
+	for _, file := range matches {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		if !goFileBuildable(file) {
+			continue
+		}
+		files = append(files, file)
+	}
+	sort.Strings(files)
+
+	g.files[dir] = files
+	return files
+}
+
+// goFileBuildable reports whether a file's build constraints can be
+// satisfied for some combination of build tags. The graph is platform
+// independent, so _linux.go and `//go:build windows` files both belong to
+// their package, while `//go:build ignore` files (generators, examples)
+// never do.
+func goFileBuildable(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	var exprs []constraint.Expr
+	lines := bufio.NewScanner(file)
+	for lines.Scan() {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" || strings.HasPrefix(line, "/*") {
+			continue
+		}
+		if !strings.HasPrefix(line, "//") {
+			// Constraints must appear before the package clause
+			break
+		}
+		if !constraint.IsGoBuild(line) && !constraint.IsPlusBuild(line) {
+			continue
+		}
+		if expr, err := constraint.Parse(line); err == nil {
+			exprs = append(exprs, expr)
+		}
+	}
+
+	for _, expr := range exprs {
+		if !constraintSatisfiable(expr) {
+			return false
+		}
+	}
+	return true
+}
+
+// constraintSatisfiable checks every assignment of the expression's tags,
+// treating "ignore" as never set
+func constraintSatisfiable(expr constraint.Expr) bool {
+	tagSet := map[string]bool{}
+	expr.Eval(func(tag string) bool {
+		tagSet[tag] = true
+		return false
+	})
+
+	var tags []string
+	for tag := range tagSet {
+		if tag != "ignore" {
+			tags = append(tags, tag)
+		}
+	}
+	// Pathologically large expressions are assumed to be satisfiable
+	if len(tags) > 16 {
+		return true
+	}
+
+	for mask := 0; mask < 1<<len(tags); mask++ {
+		set := map[string]bool{}
+		for i, tag := range tags {
+			set[tag] = mask&(1<<i) != 0
+		}
+		if expr.Eval(func(tag string) bool { return set[tag] }) {
+			return true
+		}
+	}
+	return false
+}
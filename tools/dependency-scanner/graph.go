@@ -4,20 +4,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 )
 
 type DependencyGraph struct {
-	Files       map[string]*FileNode `json:"Files"`
-	Circular    [][]string           `json:"Circular"`
-	DeadCode    []string             `json:"DeadCode"`
-	LastUpdated time.Time            `json:"LastUpdated"`
+	Files         map[string]*FileNode `json:"Files"`
+	Circular      [][]string           `json:"Circular"`
+	DeadCode      []string             `json:"DeadCode"`
+	UnusedExports []UnusedExport       `json:"UnusedExports"`  // Public symbols no other file uses
+	Languages     []string             `json:"Languages"`      // Languages the graph was scanned with
+	Root          string               `json:"Root,omitempty"` // Scanned directory, as file paths are relative to it
+	LastUpdated   time.Time            `json:"LastUpdated"`
 }
 
 type FileNode struct {
 	Path       string   `json:"Path"`
 	Language   string   `json:"Language"`
+	Package    string   `json:"Package,omitempty"`
+	EntryPoint string   `json:"EntryPoint,omitempty"` // Why the file runs without being imported, if it does
 	Imports    []Import `json:"Imports"`
 	Exports    []Export `json:"Exports"`
 	ImportedBy []string `json:"ImportedBy"`
@@ -27,98 +34,156 @@ type Import struct {
 	Path      string   `json:"Path"`
 	Symbols   []string `json:"Symbols"`
 	IsDefault bool     `json:"IsDefault"`
+	Kind      string   `json:"Kind"`
 	Line      int      `json:"Line"`
+	Uses      []string `json:"Uses,omitempty"` // Members accessed through the import's namespace, "*" if used as a whole
 }
 
+// Import edge kinds stored in Import.Kind
+const (
+	// ImportStatic is a regular ES import, Go import or Python import
+	ImportStatic = "static"
+	// ImportRequire is a CommonJS require() or TS `import x = require()`
+	ImportRequire = "require"
+	// ImportDynamic is an import() expression
+	ImportDynamic = "dynamic"
+	// ImportReExport is `export * from` or `export { a } from`
+	ImportReExport = "re-export"
+	// ImportSideEffect is `import "./x"` or a Go blank import
+	ImportSideEffect = "side-effect"
+	// ImportTypeOnly is erased at runtime (`import type`, `export type ... from`)
+	ImportTypeOnly = "type-only"
+)
+
 type Export struct {
-	Name      string `json:"Name"`
-	Type      string `json:"Type"`
-	IsDefault bool   `json:"IsDefault"`
-	Line      int    `json:"Line"`
+	Name       string `json:"Name"`
+	Type       string `json:"Type"`
+	Visibility string `json:"Visibility"`
+	IsDefault  bool   `json:"IsDefault"`
+	Line       int    `json:"Line"`
+}
+
+// Export kinds stored in Export.Type
+const (
+	ExportFunction  = "function"
+	ExportMethod    = "method"
+	ExportClass     = "class"
+	ExportInterface = "interface"
+	ExportType      = "type"
+	ExportVariable  = "variable"
+	ExportEnum      = "enum"
+	ExportNamespace = "namespace"
+)
+
+// Export visibilities stored in Export.Visibility
+const (
+	// VisibilityPublic symbols can be imported by other modules/packages
+	VisibilityPublic = "public"
+	// VisibilityPackage symbols are shared with sibling files only (Go lowercase)
+	VisibilityPackage = "package"
+	// VisibilityPrivate symbols are local to the file (non-exported JS, _python)
+	VisibilityPrivate = "private"
+)
+
+// IsPublic reports whether the symbol is visible outside its module/package
+func (e Export) IsPublic() bool {
+	return e.Visibility == VisibilityPublic
 }
 
 func NewDependencyGraph() *DependencyGraph {
 	return &DependencyGraph{
-		Files:       make(map[string]*FileNode),
-		Circular:    [][]string{},
-		DeadCode:    []string{},
-		LastUpdated: time.Now(),
+		Files:         make(map[string]*FileNode),
+		Circular:      [][]string{},
+		DeadCode:      []string{},
+		UnusedExports: []UnusedExport{},
+		Languages:     []string{},
+		LastUpdated:   time.Now(),
 	}
 }
 
-func (g *DependencyGraph) SaveJSON(outputPath string) error {
-	data, err := json.MarshalIndent(g, "", "  ")
-	if err != nil {
-		return err
+// SortedPaths returns the file paths of the graph in lexical order
+func (g *DependencyGraph) SortedPaths() []string {
+	paths := make([]string, 0, len(g.Files))
+	for path := range g.Files {
+		paths = append(paths, path)
 	}
-
-	return os.WriteFile(outputPath, data, 0644)
+	sort.Strings(paths)
+	return paths
 }
 
-func (g *DependencyGraph) SaveTOON(outputPath string) error {
-	var builder strings.Builder
-
-	for filePath, node := range g.Files {
-		builder.WriteString("FILE:")
-		builder.WriteString(filePath)
-		builder.WriteString("\n")
-
-		builder.WriteString("LANG:")
-		builder.WriteString(node.Language)
-		builder.WriteString("\n")
-
-		builder.WriteString("IMPORTS:")
-		if len(node.Imports) > 0 {
-			imports := make([]string, len(node.Imports))
-			for i, imp := range node.Imports {
-				imports[i] = fmt.Sprintf("%s:%d", imp.Path, imp.Line)
-			}
-			builder.WriteString(strings.Join(imports, ","))
+// relPath returns a file path relative to the scanned directory, with
+// forward slashes
+func (g *DependencyGraph) relPath(path string) string {
+	if g.Root != "" {
+		if rel, err := filepath.Rel(g.Root, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
 		}
-		builder.WriteString("\n")
+	}
+	return filepath.ToSlash(path)
+}
 
-		builder.WriteString("EXPORTS:")
-		if len(node.Exports) > 0 {
-			exports := make([]string, len(node.Exports))
-			for i, exp := range node.Exports {
-				exports[i] = fmt.Sprintf("%s:%s:%d", exp.Name, exp.Type, exp.Line)
-			}
-			builder.WriteString(strings.Join(exports, ","))
+// findFile resolves a file argument to its path in the graph: an exact
+// match, then the path made absolute, then a unique suffix match
+func (g *DependencyGraph) findFile(target string) (string, error) {
+	if _, ok := g.Files[target]; ok {
+		return target, nil
+	}
+
+	if abs, err := filepath.Abs(target); err == nil {
+		if _, ok := g.Files[abs]; ok {
+			return abs, nil
 		}
-		builder.WriteString("\n")
+	}
 
-		builder.WriteString("IMPORTEDBY:")
-		if len(node.ImportedBy) > 0 {
-			builder.WriteString(strings.Join(node.ImportedBy, ","))
+	suffix := "/" + strings.TrimPrefix(filepath.ToSlash(filepath.Clean(target)), "./")
+	var matches []string
+	for _, path := range g.SortedPaths() {
+		if strings.HasSuffix(filepath.ToSlash(path), suffix) {
+			matches = append(matches, path)
 		}
-		builder.WriteString("\n")
+	}
 
-		builder.WriteString("---\n")
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("file not found in dependency graph: %s", target)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%s matches %d files in the dependency graph:\n  %s",
+			target, len(matches), strings.Join(matches, "\n  "))
 	}
+}
 
-	if len(g.Circular) > 0 {
-		for _, cycle := range g.Circular {
-			builder.WriteString("CIRCULAR:")
-			builder.WriteString(strings.Join(cycle, ">"))
-			builder.WriteString("\n")
-		}
-		builder.WriteString("---\n")
+func (g *DependencyGraph) SaveJSON(outputPath string) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
 	}
 
-	if len(g.DeadCode) > 0 {
-		for _, deadFile := range g.DeadCode {
-			builder.WriteString("DEADCODE:")
-			builder.WriteString(deadFile)
-			builder.WriteString("\n")
-		}
-		builder.WriteString("---\n")
+	return writeFileAtomic(outputPath, data)
+}
+
+// LoadJSON reads a graph written by SaveJSON
+func LoadJSON(path string) (*DependencyGraph, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
 	}
 
-	builder.WriteString("META:lastUpdated=")
-	builder.WriteString(g.LastUpdated.Format(time.RFC3339))
-	builder.WriteString("\n")
+	graph := NewDependencyGraph()
+	if err := json.Unmarshal(data, graph); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return graph, nil
+}
 
-	return os.WriteFile(outputPath, []byte(builder.String()), 0644)
+// LoadGraph reads a graph saved by SaveTOON or SaveJSON, choosing the
+// format from the file extension
+func LoadGraph(path string) (*DependencyGraph, error) {
+	if outputFormat("", path) == FormatJSON {
+		return LoadJSON(path)
+	}
+	return LoadTOON(path)
 }
 
 func (g *DependencyGraph) PrintStats() {
@@ -128,13 +193,17 @@ func (g *DependencyGraph) PrintStats() {
 	}
 
 	if len(langCount) > 0 {
-		first := true
-		for lang, count := range langCount {
-			if !first {
+		languages := make([]string, 0, len(langCount))
+		for lang := range langCount {
+			languages = append(languages, lang)
+		}
+		sort.Strings(languages)
+
+		for i, lang := range languages {
+			if i > 0 {
 				print(", ")
 			}
-			printf("%s (%d)", lang, count)
-			first = false
+			printf("%s (%d)", lang, langCount[lang])
 		}
 		println()
 	}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ignoreFileNames are read in every directory; rules of later files take
+// precedence, as do rules of deeper directories
+var ignoreFileNames = []string{".gitignore", ".ignore"}
+
+// isIgnoreFile reports whether a file name holds ignore rules
+func isIgnoreFile(name string) bool {
+	for _, ignoreFile := range ignoreFileNames {
+		if name == ignoreFile {
+			return true
+		}
+	}
+	return false
+}
+
+// SkipReason explains why a path is left out of the scan
+type SkipReason struct {
+	Path   string // The excluded path, or the parent directory that is excluded
+	Rule   string // The pattern or name that matched
+	Source string // Ignore file and line, command-line flag or built-in default
+}
+
+func (r *SkipReason) String() string {
+	return fmt.Sprintf("%s: rule %q from %s", r.Path, r.Rule, r.Source)
+}
+
+// ignoreRule is one line of a .gitignore-style file
+type ignoreRule struct {
+	pattern  string // glob without leading '!' and leading/trailing '/'
+	negate   bool   // '!' re-includes what earlier rules excluded
+	dirOnly  bool   // trailing '/' only matches directories
+	anchored bool   // a '/' other than a trailing one anchors at base
+	base     string // slash-separated directory the rule is relative to, "" for the root
+	text     string // the rule as written
+	source   string // where the rule was read from
+}
+
+// parseIgnoreRule parses a line of an ignore file using gitignore syntax.
+// Returns false for blank lines and comments.
+func parseIgnoreRule(line, base, source string) (ignoreRule, bool) {
+	line = strings.TrimSuffix(line, "\r")
+	// Trailing spaces are ignored unless escaped
+	for strings.HasSuffix(line, " ") && !strings.HasSuffix(line, "\\ ") {
+		line = line[:len(line)-1]
+	}
+	if line == "" || strings.HasPrefix(line, "#") {
+		return ignoreRule{}, false
+	}
+
+	rule := ignoreRule{base: base, text: line, source: source}
+	pattern := line
+	if strings.HasPrefix(pattern, "!") {
+		rule.negate = true
+		pattern = pattern[1:]
+	}
+	if strings.HasSuffix(pattern, "/") {
+		rule.dirOnly = true
+		pattern = strings.TrimRight(pattern, "/")
+	}
+	if strings.Contains(pattern, "/") {
+		rule.anchored = true
+		pattern = strings.TrimPrefix(pattern, "/")
+	}
+	if pattern == "" {
+		return ignoreRule{}, false
+	}
+	rule.pattern = pattern
+	return rule, true
+}
+
+// parseIgnorePatterns turns command-line patterns into root-relative rules
+func parseIgnorePatterns(patterns []string, source string) []ignoreRule {
+	var rules []ignoreRule
+	for _, pattern := range patterns {
+		if rule, ok := parseIgnoreRule(pattern, "", source); ok {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// matches reports whether the rule applies to a slash-separated path
+// relative to the scan root. Unanchored rules match the base name at any
+// depth below the rule's directory.
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.base != "" {
+		if !strings.HasPrefix(rel, r.base+"/") {
+			return false
+		}
+		rel = rel[len(r.base)+1:]
+	}
+	if r.anchored {
+		return matchGlob(r.pattern, rel)
+	}
+	return matchGlob(r.pattern, path.Base(rel))
+}
+
+// ignoreMatcher evaluates .gitignore/.ignore files of the scanned tree
+// together with -exclude patterns. Ignore files are loaded lazily per
+// directory; it is safe for concurrent use.
+type ignoreMatcher struct {
+	root     string
+	excludes []ignoreRule // -exclude patterns, evaluated last
+	includes []ignoreRule // -include patterns; if set, files must match one
+
+	mu    sync.Mutex
+	rules map[string][]ignoreRule // directory -> rules of its ignore files
+}
+
+func newIgnoreMatcher(root string, excludes, includes []string) *ignoreMatcher {
+	return &ignoreMatcher{
+		root:     root,
+		excludes: parseIgnorePatterns(excludes, "-exclude"),
+		includes: parseIgnorePatterns(includes, "-include"),
+		rules:    make(map[string][]ignoreRule),
+	}
+}
+
+// reset drops loaded ignore files after one of them changed
+func (m *ignoreMatcher) reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = make(map[string][]ignoreRule)
+}
+
+// dirRules returns the rules of the ignore files in dir, whose path relative
+// to the root is rel. The root also honors .git/info/exclude.
+func (m *ignoreMatcher) dirRules(dir, rel string) []ignoreRule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rules, loaded := m.rules[dir]; loaded {
+		return rules
+	}
+
+	files := make([]string, 0, len(ignoreFileNames)+1)
+	if rel == "" {
+		files = append(files, filepath.Join(dir, ".git", "info", "exclude"))
+	}
+	for _, name := range ignoreFileNames {
+		files = append(files, filepath.Join(dir, name))
+	}
+
+	var rules []ignoreRule
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for i, line := range strings.Split(string(content), "\n") {
+			if rule, ok := parseIgnoreRule(line, rel, fmt.Sprintf("%s:%d", file, i+1)); ok {
+				rules = append(rules, rule)
+			}
+		}
+	}
+
+	m.rules[dir] = rules
+	return rules
+}
+
+// match returns the last rule matching path, considering the ignore files
+// of every directory from the root down to path's parent and then the
+// -exclude patterns. A negated result means path is explicitly included.
+func (m *ignoreMatcher) match(filePath string, isDir bool) *ignoreRule {
+	rel, ok := m.relPath(filePath)
+	if !ok || rel == "" {
+		return nil
+	}
+
+	var matched *ignoreRule
+	check := func(rules []ignoreRule) {
+		for i := range rules {
+			if rules[i].matches(rel, isDir) {
+				matched = &rules[i]
+			}
+		}
+	}
+
+	dir, dirRel := m.root, ""
+	check(m.dirRules(dir, dirRel))
+	segments := strings.Split(rel, "/")
+	for _, segment := range segments[:len(segments)-1] {
+		dir = filepath.Join(dir, segment)
+		dirRel = path.Join(dirRel, segment)
+		check(m.dirRules(dir, dirRel))
+	}
+	check(m.excludes)
+
+	return matched
+}
+
+// included reports whether a file passes the -include patterns, either
+// directly or through one of its parent directories
+func (m *ignoreMatcher) included(filePath string) bool {
+	if len(m.includes) == 0 {
+		return true
+	}
+	rel, ok := m.relPath(filePath)
+	if !ok {
+		return false
+	}
+	for _, rule := range m.includes {
+		isDir := false
+		for candidate := rel; candidate != "."; candidate = path.Dir(candidate) {
+			if rule.matches(candidate, isDir) {
+				return true
+			}
+			isDir = true
+		}
+	}
+	return false
+}
+
+// includePatterns returns the -include patterns as given
+func (m *ignoreMatcher) includePatterns() string {
+	patterns := make([]string, len(m.includes))
+	for i, rule := range m.includes {
+		patterns[i] = rule.text
+	}
+	return strings.Join(patterns, ",")
+}
+
+func (m *ignoreMatcher) relPath(filePath string) (string, bool) {
+	rel, err := filepath.Rel(m.root, filePath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+	if rel == "." {
+		return "", true
+	}
+	return filepath.ToSlash(rel), true
+}
+
+func (r *ignoreRule) reason(filePath string) *SkipReason {
+	return &SkipReason{Path: filePath, Rule: r.text, Source: r.source}
+}
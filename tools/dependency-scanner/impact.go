@@ -0,0 +1,172 @@
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ImpactOptions narrows an impact analysis
+type ImpactOptions struct {
+	MaxDepth  int      // Stop after this many hops; 0 means unlimited
+	Kinds     []string // Only follow imports of these kinds (all if empty)
+	SkipKinds []string // Never follow imports of these kinds, e.g. type-only
+	Languages []string // Only report dependents in these languages (all if empty)
+}
+
+// ImpactedFile is a file that depends, directly or transitively, on one of
+// the changed files
+type ImpactedFile struct {
+	Path     string
+	Language string
+	Distance int      // 1 for direct importers
+	Via      []string // Chain from the changed file to Path, both included
+	IsTest   bool
+}
+
+// AnalyzeImpact walks ImportedBy outward from the changed files and
+// returns every dependent with its shortest distance and the chain that
+// reaches it, ordered by distance and path. Each file is visited once, so
+// cycles cost nothing extra. Dependents in filtered-out languages are still
+// walked through, only omitted from the result.
+func AnalyzeImpact(graph *DependencyGraph, changed []string, opts ImpactOptions) []ImpactedFile {
+	follow := edgeKindFilter(graph, opts.Kinds, opts.SkipKinds)
+	report := make(map[string]bool, len(opts.Languages))
+	for _, lang := range opts.Languages {
+		report[lang] = true
+	}
+
+	// Multi-source breadth-first search; parent records the first importer
+	// edge that reached each file
+	parent := make(map[string]string)
+	distance := make(map[string]int)
+	var queue []string
+	for _, path := range changed {
+		if _, ok := graph.Files[path]; !ok {
+			continue
+		}
+		if _, seen := distance[path]; !seen {
+			distance[path] = 0
+			queue = append(queue, path)
+		}
+	}
+
+	var impacted []ImpactedFile
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if opts.MaxDepth > 0 && distance[current] >= opts.MaxDepth {
+			continue
+		}
+
+		importers := append([]string{}, graph.Files[current].ImportedBy...)
+		sort.Strings(importers)
+		for _, importer := range importers {
+			node, ok := graph.Files[importer]
+			if !ok {
+				continue
+			}
+			if _, seen := distance[importer]; seen || !follow(importer, current) {
+				continue
+			}
+			distance[importer] = distance[current] + 1
+			parent[importer] = current
+			queue = append(queue, importer)
+
+			if len(report) > 0 && !report[node.Language] {
+				continue
+			}
+			impacted = append(impacted, ImpactedFile{
+				Path:     importer,
+				Language: node.Language,
+				Distance: distance[importer],
+				Via:      impactChain(parent, distance, importer),
+				IsTest:   isTestFile(graph.relPath(importer)),
+			})
+		}
+	}
+
+	sort.SliceStable(impacted, func(i, j int) bool {
+		if impacted[i].Distance != impacted[j].Distance {
+			return impacted[i].Distance < impacted[j].Distance
+		}
+		return impacted[i].Path < impacted[j].Path
+	})
+	return impacted
+}
+
+// impactChain follows parent links back to a changed file
+func impactChain(parent map[string]string, distance map[string]int, path string) []string {
+	chain := make([]string, distance[path]+1)
+	for i := len(chain) - 1; i >= 0; i-- {
+		chain[i] = path
+		path = parent[path]
+	}
+	return chain
+}
+
+// edgeKindFilter reports whether the importer -> imported edge should be
+// followed, given the import kinds to keep and to skip. A file importing
+// another several ways is followed if any of those imports qualifies.
+func edgeKindFilter(graph *DependencyGraph, kinds, skipKinds []string) func(importer, imported string) bool {
+	if len(kinds) == 0 && len(skipKinds) == 0 {
+		return func(string, string) bool { return true }
+	}
+
+	keep := make(map[string]bool, len(kinds))
+	for _, kind := range kinds {
+		keep[kind] = true
+	}
+	skip := make(map[string]bool, len(skipKinds))
+	for _, kind := range skipKinds {
+		skip[kind] = true
+	}
+
+	return func(importer, imported string) bool {
+		for _, imp := range graph.Files[importer].Imports {
+			if imp.Path != imported || skip[imp.Kind] {
+				continue
+			}
+			if len(keep) == 0 || keep[imp.Kind] {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// testDirs are directory names that hold tests
+var testDirs = map[string]bool{
+	"test":      true,
+	"tests":     true,
+	"__tests__": true,
+	"spec":      true,
+}
+
+// isTestFile recognises test files by the naming conventions of each
+// supported language: foo_test.go, foo.test.ts, foo.spec.js, test_foo.py,
+// foo_test.py, conftest.py and files under test directories. The path must
+// be relative to the project root.
+func isTestFile(rel string) bool {
+	slashed := filepath.ToSlash(rel)
+	name := slashed[strings.LastIndex(slashed, "/")+1:]
+	ext := filepath.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+
+	switch {
+	case strings.HasSuffix(name, "_test.go"):
+		return true
+	case strings.HasSuffix(stem, ".test"), strings.HasSuffix(stem, ".spec"):
+		return true
+	case ext == ".py" && (strings.HasPrefix(stem, "test_") || strings.HasSuffix(stem, "_test") || stem == "conftest"):
+		return true
+	}
+
+	dirs := strings.Split(slashed, "/")
+	for _, dir := range dirs[:len(dirs)-1] {
+		if testDirs[dir] {
+			return true
+		}
+	}
+	return false
+}
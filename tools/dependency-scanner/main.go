@@ -4,7 +4,11 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
 	"strings"
+	"syscall"
 	"time"
 )
 
@@ -21,10 +25,33 @@ func print(args ...interface{}) {
 }
 
 func main() {
+	// Queries answer from a saved graph instead of scanning
+	if len(os.Args) > 1 && os.Args[1] == "query" {
+		os.Exit(runQuery(os.Args[2:]))
+	}
+	// Checks enforce the project's architecture rules
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(runCheck(os.Args[2:]))
+	}
+
 	pathFlag := flag.String("path", ".", "Path to scan for dependencies")
-	outputFlag := flag.String("output", "", "Output file path for graph (default: .claude/dep-graph.toon)")
-	excludeFlag := flag.String("exclude", "", "Comma-separated list of additional directories to exclude")
+	outputFlag := flag.String("output", "", "Output file path for graph (default: <path>/.claude/dep-graph.toon)")
+	formatFlag := flag.String("format", "", "Output format: toon, json, dot, mermaid or html (default: from the output extension)")
+	focusFlag := flag.String("focus", "", "dot/mermaid: comma-separated files; only draw files within -hops imports of them")
+	hopsFlag := flag.Int("hops", 2, "dot/mermaid: how many imports away from the -focus files to draw, in either direction")
+	clusterFlag := flag.String("cluster", ClusterDir, "dot/mermaid: group files by dir, package or none")
+	languagesFlag := flag.String("languages", "", "Comma-separated languages to parse (default: from the project config)")
+	excludeFlag := flag.String("exclude", "", "Comma-separated gitignore-style patterns to exclude (e.g. legacy,packages/old/**,*.generated.ts)")
+	includeFlag := flag.String("include", "", "Comma-separated gitignore-style patterns; only matching files are scanned")
+	explainSkipFlag := flag.String("explain-skip", "", "Report which rule excludes the given path and exit")
 	verboseFlag := flag.Bool("verbose", false, "Enable verbose output")
+	fullFlag := flag.Bool("full", false, "Ignore the parse cache and re-parse every file")
+	cacheFlag := flag.String("cache", "", "Parse cache directory (default: <path>/.claude/dep-cache)")
+	jobsFlag := flag.Int("jobs", runtime.GOMAXPROCS(0), "Number of files to parse in parallel")
+	watchFlag := flag.Bool("watch", false, "Keep running and update the graph when files change")
+	pollFlag := flag.Bool("poll", false, "Watch by polling instead of native file notifications")
+	intervalFlag := flag.Duration("interval", time.Second, "Poll interval in watch mode")
+	debounceFlag := flag.Duration("debounce", 300*time.Millisecond, "Wait this long after the last change before updating")
 	versionFlag := flag.Bool("version", false, "Show version information")
 
 	flag.Parse()
@@ -35,45 +62,106 @@ func main() {
 		os.Exit(0)
 	}
 
-	outputPath := *outputFlag
-	if outputPath == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Failed to get home directory: %v\n", err)
-			os.Exit(1)
-		}
-		outputPath = fmt.Sprintf("%s/.claude/dep-graph.toon", homeDir)
+	// Settings come from .claude/dep-scanner.json unless given as flags
+	config, err := loadProjectConfig(*pathFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to load project config: %v\n", err)
+		os.Exit(1)
 	}
+	flagSet := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		flagSet[f.Name] = true
+	})
 
-	startTime := time.Now()
+	outputPath := config.outputPath(*pathFlag)
+	if flagSet["output"] {
+		outputPath = *outputFlag
+	}
+
+	format := config.Format
+	if flagSet["format"] {
+		format = *formatFlag
+	}
+	format = outputFormat(format, outputPath)
+	switch format {
+	case FormatTOON, FormatJSON, FormatDOT, FormatMermaid, FormatHTML:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown format %q (want %s, %s, %s, %s or %s)\n", format, FormatTOON, FormatJSON, FormatDOT, FormatMermaid, FormatHTML)
+		os.Exit(1)
+	}
+
+	diagram := DiagramOptions{Focus: splitPatterns(*focusFlag), Hops: *hopsFlag, Cluster: *clusterFlag}
+	switch diagram.Cluster {
+	case ClusterDir, ClusterPackage, ClusterNone:
+	default:
+		fmt.Fprintf(os.Stderr, "Error: Unknown cluster mode %q (want %s, %s or %s)\n", diagram.Cluster, ClusterDir, ClusterPackage, ClusterNone)
+		os.Exit(1)
+	}
+	if (flagSet["focus"] || flagSet["hops"] || flagSet["cluster"]) && format != FormatDOT && format != FormatMermaid {
+		fmt.Fprintf(os.Stderr, "Error: -focus, -hops and -cluster only apply to %s and %s output\n", FormatDOT, FormatMermaid)
+		os.Exit(1)
+	}
+
+	languages := config.Languages
+	if flagSet["languages"] {
+		languages = splitPatterns(*languagesFlag)
+	}
 
 	// Parse exclusions
-	var excludeDirs []string
-	if *excludeFlag != "" {
-		for _, dir := range strings.Split(*excludeFlag, ",") {
-			dir = strings.TrimSpace(dir)
-			if dir != "" {
-				excludeDirs = append(excludeDirs, dir)
-			}
-		}
+	excludes := config.Exclude
+	if flagSet["exclude"] {
+		excludes = splitPatterns(*excludeFlag)
+	}
+	includes := config.Include
+	if flagSet["include"] {
+		includes = splitPatterns(*includeFlag)
+	}
+
+	cacheDir := config.cacheDir(*pathFlag)
+	if flagSet["cache"] {
+		cacheDir = *cacheFlag
 	}
 
-	if *verboseFlag {
+	startTime := time.Now()
+
+	if *verboseFlag && *explainSkipFlag == "" {
 		fmt.Printf("Starting dependency scan...\n")
 		fmt.Printf("Path: %s\n", *pathFlag)
-		fmt.Printf("Output: %s\n", outputPath)
-		if len(excludeDirs) > 0 {
-			fmt.Printf("Additional exclusions: %v\n", excludeDirs)
+		if config.path != "" {
+			fmt.Printf("Config: %s\n", config.path)
+		}
+		fmt.Printf("Output: %s (%s)\n", outputPath, format)
+		if len(excludes) > 0 {
+			fmt.Printf("Additional exclusions: %v\n", excludes)
 		}
 		fmt.Println()
 	}
 
-	scanner, err := NewScanner(*pathFlag, *verboseFlag, excludeDirs)
+	scanner, err := NewScanner(*pathFlag, ScannerOptions{
+		Verbose:     *verboseFlag,
+		Languages:   languages,
+		EntryPoints: config.EntryPoints,
+		Aliases:     config.Aliases,
+		Excludes:    excludes,
+		Includes:    includes,
+		Jobs:        *jobsFlag,
+		CacheDir:    cacheDir,
+		Full:        *fullFlag,
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Failed to create scanner: %v\n", err)
 		os.Exit(1)
 	}
 
+	if *explainSkipFlag != "" {
+		if reason := scanner.ExplainSkip(*explainSkipFlag); reason != nil {
+			fmt.Printf("%s is excluded: %s\n", *explainSkipFlag, reason)
+		} else {
+			fmt.Printf("%s is not excluded\n", *explainSkipFlag)
+		}
+		os.Exit(0)
+	}
+
 	if err := scanner.Scan(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: Scan failed: %v\n", err)
 		os.Exit(1)
@@ -81,16 +169,9 @@ func main() {
 
 	graph := scanner.GetGraph()
 
-	if strings.HasSuffix(outputPath, ".json") {
-		if err := graph.SaveJSON(outputPath); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Failed to save graph: %v\n", err)
-			os.Exit(1)
-		}
-	} else {
-		if err := graph.SaveTOON(outputPath); err != nil {
-			fmt.Fprintf(os.Stderr, "Error: Failed to save graph: %v\n", err)
-			os.Exit(1)
-		}
+	if err := saveGraph(graph, outputPath, format, diagram); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to save graph: %v\n", err)
+		os.Exit(1)
 	}
 
 	elapsed := time.Since(startTime)
@@ -105,12 +186,81 @@ func main() {
 	}
 
 	if len(graph.DeadCode) > 0 {
-		fmt.Printf("Potentially unused files: %d\n", len(graph.DeadCode))
+		fmt.Printf("Unreachable files: %d\n", len(graph.DeadCode))
 	} else {
 		fmt.Printf("No dead code detected\n")
 	}
 
+	if len(graph.UnusedExports) > 0 {
+		fmt.Printf("Unused exports: %d\n", len(graph.UnusedExports))
+	}
+
 	graph.PrintStats()
 
 	fmt.Printf("Completed in: %.2fs\n", elapsed.Seconds())
+
+	if *watchFlag {
+		watch(scanner, outputPath, format, diagram, WatchOptions{
+			Debounce: *debounceFlag,
+			Interval: *intervalFlag,
+			Poll:     *pollFlag,
+		})
+	}
+}
+
+// splitPatterns splits a comma-separated flag value into trimmed patterns
+func splitPatterns(value string) []string {
+	var patterns []string
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern != "" {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
+}
+
+// saveGraph writes the graph in the given format, creating the output
+// directory if needed. Diagram options apply to DOT and Mermaid only.
+func saveGraph(graph *DependencyGraph, outputPath, format string, diagram DiagramOptions) error {
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return err
+	}
+	switch format {
+	case FormatJSON:
+		return graph.SaveJSON(outputPath)
+	case FormatDOT:
+		return graph.SaveDOT(outputPath, diagram)
+	case FormatMermaid:
+		return graph.SaveMermaid(outputPath, diagram)
+	case FormatHTML:
+		return graph.SaveHTML(outputPath)
+	}
+	return graph.SaveTOON(outputPath)
+}
+
+// watch rewrites the output after every batch of changes until interrupted
+func watch(scanner *Scanner, outputPath, format string, diagram DiagramOptions, opts WatchOptions) {
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+
+	fmt.Printf("\nWatching for changes (Ctrl+C to stop)...\n")
+
+	err := scanner.Watch(opts, stop, func(graph *DependencyGraph, changed []string) {
+		if err := saveGraph(graph, outputPath, format, diagram); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: Failed to save graph: %v\n", err)
+			return
+		}
+		fmt.Printf("[%s] %d files changed, graph updated (%d files)\n",
+			time.Now().Format("15:04:05"), len(changed), len(graph.Files))
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Watch failed: %v\n", err)
+		os.Exit(1)
+	}
 }
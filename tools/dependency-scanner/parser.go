@@ -14,29 +14,33 @@ import (
 	"github.com/smacker/go-tree-sitter/typescript/typescript"
 )
 
-// Parser handles parsing files with tree-sitter
+// Parser handles parsing files with tree-sitter. A Parser reuses one
+// tree-sitter parser per language and is not safe for concurrent use;
+// create one per worker goroutine.
 type Parser struct {
 	languages map[string]*sitter.Language
+	parsers   map[string]*sitter.Parser
+}
+
+// grammars maps language names to their tree-sitter grammar
+var grammars = map[string]*sitter.Language{
+	"typescript": typescript.GetLanguage(),
+	"tsx":        tsx.GetLanguage(),
+	"javascript": javascript.GetLanguage(),
+	"go":         golang.GetLanguage(),
+	"python":     python.GetLanguage(),
 }
 
 // NewParser creates a new parser with specified languages
 func NewParser(languageNames []string) (*Parser, error) {
 	p := &Parser{
 		languages: make(map[string]*sitter.Language),
-	}
-
-	// Map language names to their grammar
-	languageMap := map[string]*sitter.Language{
-		"typescript": typescript.GetLanguage(),
-		"tsx":        tsx.GetLanguage(),
-		"javascript": javascript.GetLanguage(),
-		"go":         golang.GetLanguage(),
-		"python":     python.GetLanguage(),
+		parsers:   make(map[string]*sitter.Parser),
 	}
 
 	// Load requested languages
 	for _, lang := range languageNames {
-		if grammar, ok := languageMap[lang]; ok {
+		if grammar, ok := grammars[lang]; ok {
 			if grammar == nil {
 				continue
 			}
@@ -49,8 +53,20 @@ func NewParser(languageNames []string) (*Parser, error) {
 
 // Parse parses a file and returns a FileNode
 func (p *Parser) Parse(filePath string) (*FileNode, error) {
-	// Determine language from extension
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return p.ParseContent(filePath, content)
+}
+
+// ParseContent parses already loaded file content and returns a FileNode
+func (p *Parser) ParseContent(filePath string, content []byte) (*FileNode, error) {
+	// Determine language from extension, or the shebang of a script
 	lang := p.detectLanguage(filePath)
+	if lang == "" && filepath.Ext(filePath) == "" {
+		lang = shebangContentLanguage(content)
+	}
 	if lang == "" {
 		return nil, fmt.Errorf("unsupported file type: %s", filePath)
 	}
@@ -65,21 +81,17 @@ func (p *Parser) Parse(filePath string) (*FileNode, error) {
 		return nil, fmt.Errorf("grammar is nil for language: %s", lang)
 	}
 
-	// Read file
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		return nil, err
-	}
-
-	// Create parser
-	parser := sitter.NewParser()
-	if parser == nil {
-		return nil, fmt.Errorf("failed to create parser")
+	// Reuse this worker's parser for the language
+	parser, ok := p.parsers[lang]
+	if !ok {
+		parser = sitter.NewParser()
+		if parser == nil {
+			return nil, fmt.Errorf("failed to create parser")
+		}
+		parser.SetLanguage(grammar)
+		p.parsers[lang] = parser
 	}
 
-	// Set language
-	parser.SetLanguage(grammar)
-
 	// Parse
 	tree := parser.Parse(nil, content)
 	if tree == nil {
@@ -99,7 +111,9 @@ func (p *Parser) Parse(filePath string) (*FileNode, error) {
 	// Extract imports and exports using queries
 	root := tree.RootNode()
 	node.Imports = p.extractImports(root, content, lang)
+	p.collectUses(root, content, lang, node.Imports)
 	node.Exports = p.extractExports(root, content, lang)
+	node.EntryPoint = p.detectEntryPoint(root, content, lang, filePath)
 
 	return node, nil
 }
@@ -123,7 +137,7 @@ func (p *Parser) detectLanguage(filePath string) string {
 			return "tsx"
 		}
 		return "typescript"
-	case ".ts":
+	case ".ts", ".mts", ".cts":
 		return "typescript"
 	case ".jsx":
 		return "javascript"
@@ -166,24 +180,130 @@ func (p *Parser) traverseJSImports(node *sitter.Node, content []byte) []Import {
 
 	var traverse func(*sitter.Node)
 	traverse = func(n *sitter.Node) {
-		if n.Type() == "import_statement" {
-			// Find string child (the import path)
+		switch n.Type() {
+		case "import_statement":
+			symbols := []string{}
+			isDefault := false
+			kind := ImportSideEffect
+			typeOnly := false
+			var source *sitter.Node
+
 			for i := 0; i < int(n.ChildCount()); i++ {
 				child := n.Child(i)
-				if child.Type() == "string" {
-					pathText := content[child.StartByte():child.EndByte()]
-					path := string(pathText)
-					path = strings.Trim(path, "'\"")
+				switch child.Type() {
+				case "import_clause":
+					symbols, isDefault = jsImportSymbols(child, content)
+					kind = ImportStatic
+					if jsTypeOnlyClause(child) {
+						kind = ImportTypeOnly
+					}
+				case "import_require_clause":
+					// import fs = require("fs")
+					source = child.ChildByFieldName("source")
+					kind = ImportRequire
+					for j := 0; j < int(child.NamedChildCount()); j++ {
+						if ident := child.NamedChild(j); ident.Type() == "identifier" {
+							symbols = append(symbols, "* as "+nodeText(ident, content))
+							break
+						}
+					}
+				case "type":
+					// import type { T } from "./t"
+					typeOnly = true
+				case "string":
+					source = child
+				}
+			}
+			if typeOnly {
+				kind = ImportTypeOnly
+			}
 
-					imports = append(imports, Import{
-						Path:      path,
-						Symbols:   []string{},
-						IsDefault: false,
-						Line:      int(n.StartPoint().Row) + 1,
-					})
-					break
+			if source != nil {
+				imports = append(imports, Import{
+					Path:      jsStringValue(source, content),
+					Symbols:   symbols,
+					IsDefault: isDefault,
+					Kind:      kind,
+					Line:      int(n.StartPoint().Row) + 1,
+				})
+			}
+
+		case "export_statement":
+			// export * from "./x", export { a } from "./x"
+			source := n.ChildByFieldName("source")
+			if source == nil {
+				break
+			}
+			symbols := []string{}
+			kind := ImportReExport
+			for i := 0; i < int(n.ChildCount()); i++ {
+				child := n.Child(i)
+				switch child.Type() {
+				case "*":
+					symbols = append(symbols, "*")
+				case "type":
+					kind = ImportTypeOnly
+				case "namespace_export":
+					for j := 0; j < int(child.NamedChildCount()); j++ {
+						if ident := child.NamedChild(j); ident.Type() == "identifier" {
+							symbols = append(symbols, "* as "+nodeText(ident, content))
+						}
+					}
+				case "export_clause":
+					for j := 0; j < int(child.NamedChildCount()); j++ {
+						spec := child.NamedChild(j)
+						if spec.Type() != "export_specifier" {
+							continue
+						}
+						symbol := nodeText(spec.ChildByFieldName("name"), content)
+						if alias := spec.ChildByFieldName("alias"); alias != nil {
+							symbol += " as " + nodeText(alias, content)
+						}
+						symbols = append(symbols, symbol)
+					}
 				}
 			}
+
+			imports = append(imports, Import{
+				Path:      jsStringValue(source, content),
+				Symbols:   symbols,
+				IsDefault: false,
+				Kind:      kind,
+				Line:      int(n.StartPoint().Row) + 1,
+			})
+
+		case "call_expression":
+			// require("./x") and import("./x") with a literal specifier
+			function := n.ChildByFieldName("function")
+			if function == nil {
+				break
+			}
+			kind := ""
+			if function.Type() == "import" {
+				kind = ImportDynamic
+			} else if function.Type() == "identifier" && nodeText(function, content) == "require" {
+				kind = ImportRequire
+			}
+			if kind == "" {
+				break
+			}
+
+			args := n.ChildByFieldName("arguments")
+			if args == nil || args.NamedChildCount() == 0 {
+				break
+			}
+			arg := args.NamedChild(0)
+			if !jsIsStaticString(arg) {
+				break
+			}
+
+			imports = append(imports, Import{
+				Path:      jsStringValue(arg, content),
+				Symbols:   jsBindingSymbols(n, content),
+				IsDefault: false,
+				Kind:      kind,
+				Line:      int(n.StartPoint().Row) + 1,
+			})
 		}
 
 		// Recurse to children
@@ -196,6 +316,133 @@ func (p *Parser) traverseJSImports(node *sitter.Node, content []byte) []Import {
 	return imports
 }
 
+// jsStringValue returns the unquoted value of a string or template literal
+func jsStringValue(n *sitter.Node, content []byte) string {
+	return strings.Trim(nodeText(n, content), "'\"`")
+}
+
+// jsIsStaticString reports whether a node is a string literal or a template
+// literal without substitutions
+func jsIsStaticString(n *sitter.Node) bool {
+	switch n.Type() {
+	case "string":
+		return true
+	case "template_string":
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			if n.NamedChild(i).Type() == "template_substitution" {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// jsTypeOnlyClause reports whether every named import is marked `type`,
+// as in `import { type A, type B } from "./x"`
+func jsTypeOnlyClause(clause *sitter.Node) bool {
+	found := false
+	for i := 0; i < int(clause.NamedChildCount()); i++ {
+		child := clause.NamedChild(i)
+		if child.Type() != "named_imports" {
+			return false
+		}
+		for j := 0; j < int(child.NamedChildCount()); j++ {
+			spec := child.NamedChild(j)
+			if spec.Type() != "import_specifier" {
+				continue
+			}
+			if spec.ChildCount() == 0 || spec.Child(0).Type() != "type" {
+				return false
+			}
+			found = true
+		}
+	}
+	return found
+}
+
+// jsBindingSymbols derives symbols from the variable a require()/import()
+// call is assigned to: `const m = require(x)` binds "* as m" and
+// `const { a, b: c } = require(x)` binds "a" and "b as c"
+func jsBindingSymbols(call *sitter.Node, content []byte) []string {
+	symbols := []string{}
+
+	parent := call.Parent()
+	if parent != nil && parent.Type() == "await_expression" {
+		parent = parent.Parent()
+	}
+	if parent == nil || parent.Type() != "variable_declarator" {
+		return symbols
+	}
+
+	name := parent.ChildByFieldName("name")
+	if name == nil {
+		return symbols
+	}
+
+	switch name.Type() {
+	case "identifier":
+		symbols = append(symbols, "* as "+nodeText(name, content))
+	case "object_pattern":
+		for i := 0; i < int(name.NamedChildCount()); i++ {
+			prop := name.NamedChild(i)
+			switch prop.Type() {
+			case "shorthand_property_identifier_pattern":
+				symbols = append(symbols, nodeText(prop, content))
+			case "pair_pattern":
+				key := nodeText(prop.ChildByFieldName("key"), content)
+				value := prop.ChildByFieldName("value")
+				if value != nil && value.Type() == "identifier" && nodeText(value, content) != key {
+					key += " as " + nodeText(value, content)
+				}
+				symbols = append(symbols, key)
+			}
+		}
+	}
+
+	return symbols
+}
+
+// jsImportSymbols lists the bindings of an ES import clause.
+// Named imports are recorded as "name" or "name as alias", the default
+// binding as "default as Local" and a namespace import as "* as ns".
+func jsImportSymbols(clause *sitter.Node, content []byte) ([]string, bool) {
+	symbols := []string{}
+	isDefault := false
+
+	for i := 0; i < int(clause.NamedChildCount()); i++ {
+		child := clause.NamedChild(i)
+		switch child.Type() {
+		case "identifier":
+			symbols = append(symbols, "default as "+nodeText(child, content))
+			isDefault = true
+		case "namespace_import":
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				if ident := child.NamedChild(j); ident.Type() == "identifier" {
+					symbols = append(symbols, "* as "+nodeText(ident, content))
+				}
+			}
+		case "named_imports":
+			for j := 0; j < int(child.NamedChildCount()); j++ {
+				spec := child.NamedChild(j)
+				if spec.Type() != "import_specifier" {
+					continue
+				}
+				symbol := nodeText(spec.ChildByFieldName("name"), content)
+				if alias := spec.ChildByFieldName("alias"); alias != nil {
+					symbol += " as " + nodeText(alias, content)
+				}
+				if strings.HasPrefix(symbol, "default ") {
+					isDefault = true
+				}
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+
+	return symbols, isDefault
+}
+
 // extractGoImports extracts Go imports
 func (p *Parser) extractGoImports(root *sitter.Node, content []byte) []Import {
 	var imports []Import
@@ -211,10 +458,22 @@ func (p *Parser) extractGoImports(root *sitter.Node, content []byte) []Import {
 					path := string(pathText)
 					path = strings.Trim(path, "`\"")
 
+					// Named imports bind the package to an alias, "_" or "."
+					symbols := []string{}
+					if name := n.ChildByFieldName("name"); name != nil {
+						symbols = append(symbols, nodeText(name, content))
+					}
+
+					kind := ImportStatic
+					if len(symbols) == 1 && symbols[0] == "_" {
+						kind = ImportSideEffect
+					}
+
 					imports = append(imports, Import{
 						Path:      path,
-						Symbols:   []string{},
+						Symbols:   symbols,
 						IsDefault: false,
+						Kind:      kind,
 						Line:      int(n.StartPoint().Row) + 1,
 					})
 					break
@@ -238,41 +497,54 @@ func (p *Parser) extractPythonImports(root *sitter.Node, content []byte) []Impor
 
 	var traverse func(*sitter.Node)
 	traverse = func(n *sitter.Node) {
-		// Handle: import module
+		// Handle: import module, import module as alias
 		if n.Type() == "import_statement" {
 			for i := 0; i < int(n.ChildCount()); i++ {
-				child := n.Child(i)
-				if child.Type() == "dotted_name" || child.Type() == "aliased_import" {
-					nameText := content[child.StartByte():child.EndByte()]
-					path := string(nameText)
-
-					imports = append(imports, Import{
-						Path:      path,
-						Symbols:   []string{},
-						IsDefault: false,
-						Line:      int(n.StartPoint().Row) + 1,
-					})
+				if n.FieldNameForChild(i) != "name" {
+					continue
+				}
+				path, alias := pythonImportName(n.Child(i), content)
+				symbols := []string{}
+				if alias != "" {
+					symbols = append(symbols, "* as "+alias)
 				}
+
+				imports = append(imports, Import{
+					Path:      path,
+					Symbols:   symbols,
+					IsDefault: false,
+					Kind:      ImportStatic,
+					Line:      int(n.StartPoint().Row) + 1,
+				})
 			}
 		}
 
-		// Handle: from module import ...
+		// Handle: from module import name, name as alias, *
 		if n.Type() == "import_from_statement" {
-			var modulePath string
+			modulePath := nodeText(n.ChildByFieldName("module_name"), content)
+			symbols := []string{}
 			for i := 0; i < int(n.ChildCount()); i++ {
 				child := n.Child(i)
-				if child.Type() == "dotted_name" || child.Type() == "relative_import" {
-					moduleText := content[child.StartByte():child.EndByte()]
-					modulePath = string(moduleText)
-					break
+				if child.Type() == "wildcard_import" {
+					symbols = append(symbols, "*")
+					continue
 				}
+				if n.FieldNameForChild(i) != "name" {
+					continue
+				}
+				name, alias := pythonImportName(child, content)
+				if alias != "" {
+					name += " as " + alias
+				}
+				symbols = append(symbols, name)
 			}
 
 			if modulePath != "" {
 				imports = append(imports, Import{
 					Path:      modulePath,
-					Symbols:   []string{},
+					Symbols:   symbols,
 					IsDefault: false,
+					Kind:      ImportStatic,
 					Line:      int(n.StartPoint().Row) + 1,
 				})
 			}
@@ -288,63 +560,25 @@ func (p *Parser) extractPythonImports(root *sitter.Node, content []byte) []Impor
 	return imports
 }
 
-// extractExports extracts export statements from AST
+// pythonImportName splits a dotted_name or aliased_import into name and alias
+func pythonImportName(n *sitter.Node, content []byte) (string, string) {
+	if n.Type() == "aliased_import" {
+		return nodeText(n.ChildByFieldName("name"), content), nodeText(n.ChildByFieldName("alias"), content)
+	}
+	return nodeText(n, content), ""
+}
+
+// extractExports extracts top-level symbols and their visibility from AST
 func (p *Parser) extractExports(root *sitter.Node, content []byte, lang string) []Export {
 	var exports []Export
 
-	// Query patterns vary by language
-	var queryStr string
 	switch lang {
-	case "typescript", "javascript":
-		queryStr = `
-			(export_statement) @export
-			(function_declaration) @export
-			(class_declaration) @export
-		`
+	case "typescript", "javascript", "tsx":
+		exports = p.extractJSExports(root, content)
 	case "go":
-		queryStr = `
-			(function_declaration) @export
-			(type_declaration) @export
-		`
+		exports = p.extractGoExports(root, content)
 	case "python":
-		queryStr = `
-			(function_definition) @export
-			(class_definition) @export
-		`
-	default:
-		return exports
-	}
-
-	// Create query
-	query, err := sitter.NewQuery([]byte(queryStr), p.languages[lang])
-	if err != nil {
-		return exports
-	}
-
-	// Execute query
-	cursor := sitter.NewQueryCursor()
-	cursor.Exec(query, root)
-
-	// Process matches
-	for {
-		match, ok := cursor.NextMatch()
-		if !ok {
-			break
-		}
-
-		for _, capture := range match.Captures {
-			node := capture.Node
-			line := int(node.StartPoint().Row) + 1
-
-			// Extract name (simplified - would need more sophisticated extraction)
-			exp := Export{
-				Name:      "exported_symbol",
-				Type:      "function",
-				IsDefault: false,
-				Line:      line,
-			}
-			exports = append(exports, exp)
-		}
+		exports = p.extractPythonExports(root, content)
 	}
 
 	return exports
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// PathHop is one import along a dependency path
+type PathHop struct {
+	File   string // Importing file
+	Import Import // Import of the next file, with its line and symbols
+}
+
+// DependencyPath is a chain of imports from one file to another
+type DependencyPath []PathHop
+
+// importEdge is a dependency of one file on another within the graph
+type importEdge struct {
+	to   string
+	imp  Import
+	also []Import // Later imports of the same file, such as a type-only one
+}
+
+// statements returns every import behind the edge, in source order
+func (e importEdge) statements() []Import {
+	return append([]Import{e.imp}, e.also...)
+}
+
+// importEdges returns each file's dependencies on other files of the graph,
+// one edge per imported file in source order. When a file imports another
+// several times, the edge carries the first import's line and the symbols
+// of all of them, and keeps the later imports in also.
+func importEdges(graph *DependencyGraph) map[string][]importEdge {
+	edges := make(map[string][]importEdge, len(graph.Files))
+	for path, node := range graph.Files {
+		index := make(map[string]int)
+		for _, imp := range node.Imports {
+			if _, ok := graph.Files[imp.Path]; !ok {
+				continue
+			}
+			if i, seen := index[imp.Path]; seen {
+				edge := &edges[path][i]
+				edge.imp.Symbols = appendMissing(edge.imp.Symbols, imp.Symbols)
+				edge.also = append(edge.also, imp)
+				continue
+			}
+			imp.Symbols = append([]string{}, imp.Symbols...)
+			index[imp.Path] = len(edges[path])
+			edges[path] = append(edges[path], importEdge{to: imp.Path, imp: imp})
+		}
+	}
+	return edges
+}
+
+// appendMissing appends the values not already in list
+func appendMissing(list, values []string) []string {
+	for _, value := range values {
+		found := false
+		for _, existing := range list {
+			if existing == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+// pathFinder searches for import chains between files
+type pathFinder struct {
+	edges map[string][]importEdge
+}
+
+func newPathFinder(graph *DependencyGraph) *pathFinder {
+	return &pathFinder{edges: importEdges(graph)}
+}
+
+// ShortestPath returns the shortest import chain from one file to another,
+// or nil if from does not depend on to
+func ShortestPath(graph *DependencyGraph, from, to string) DependencyPath {
+	f := newPathFinder(graph)
+	return f.hops(f.shortest(from, to, nil, nil))
+}
+
+// KShortestPaths returns up to k loop-free import chains from one file to
+// another in order of length, using Yen's algorithm
+func KShortestPaths(graph *DependencyGraph, from, to string, k int) []DependencyPath {
+	f := newPathFinder(graph)
+
+	first := f.shortest(from, to, nil, nil)
+	if first == nil {
+		return nil
+	}
+	found := [][]string{first}
+	var candidates [][]string
+
+	for len(found) < k {
+		previous := found[len(found)-1]
+
+		// Deviate from the previous path at each of its files in turn
+		for i := 0; i < len(previous)-1; i++ {
+			spur, root := previous[i], previous[:i+1]
+
+			// Forbid the edges that found paths with the same root take next,
+			// and the root's files so the new path stays loop-free
+			removedEdges := make(map[[2]string]bool)
+			for _, path := range found {
+				if len(path) > i+1 && equalPaths(path[:i+1], root) {
+					removedEdges[[2]string{path[i], path[i+1]}] = true
+				}
+			}
+			removedFiles := make(map[string]bool, i)
+			for _, file := range root[:i] {
+				removedFiles[file] = true
+			}
+
+			spurPath := f.shortest(spur, to, removedEdges, removedFiles)
+			if spurPath == nil {
+				continue
+			}
+			candidate := append(append([]string{}, root[:i]...), spurPath...)
+			if !containsPath(found, candidate) && !containsPath(candidates, candidate) {
+				candidates = append(candidates, candidate)
+			}
+		}
+
+		if len(candidates) == 0 {
+			break
+		}
+		sortPaths(candidates)
+		found = append(found, candidates[0])
+		candidates = candidates[1:]
+	}
+
+	paths := make([]DependencyPath, len(found))
+	for i, path := range found {
+		paths[i] = f.hops(path)
+	}
+	return paths
+}
+
+// AllPaths returns every loop-free import chain from one file to another
+// of at most maxHops imports, stopping after limit paths. Paths are ordered
+// by length.
+func AllPaths(graph *DependencyGraph, from, to string, maxHops, limit int) []DependencyPath {
+	f := newPathFinder(graph)
+	var found [][]string
+	onPath := map[string]bool{from: true}
+	path := []string{from}
+
+	var visit func(file string)
+	visit = func(file string) {
+		if len(found) >= limit {
+			return
+		}
+		if file == to {
+			found = append(found, append([]string{}, path...))
+			return
+		}
+		if len(path) > maxHops {
+			return
+		}
+		for _, edge := range f.edges[file] {
+			if onPath[edge.to] {
+				continue
+			}
+			onPath[edge.to] = true
+			path = append(path, edge.to)
+			visit(edge.to)
+			path = path[:len(path)-1]
+			onPath[edge.to] = false
+		}
+	}
+	if _, ok := graph.Files[from]; ok && from != to {
+		visit(from)
+	}
+
+	sortPaths(found)
+	paths := make([]DependencyPath, len(found))
+	for i, path := range found {
+		paths[i] = f.hops(path)
+	}
+	return paths
+}
+
+// shortest finds the files along a shortest chain from one file to
+// another by breadth-first search, avoiding the given edges and files
+func (f *pathFinder) shortest(from, to string, removedEdges map[[2]string]bool, removedFiles map[string]bool) []string {
+	parent := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == to {
+			break
+		}
+		for _, edge := range f.edges[current] {
+			if _, seen := parent[edge.to]; seen || removedFiles[edge.to] || removedEdges[[2]string{current, edge.to}] {
+				continue
+			}
+			parent[edge.to] = current
+			queue = append(queue, edge.to)
+		}
+	}
+
+	if _, ok := parent[to]; !ok || from == to {
+		return nil
+	}
+	var path []string
+	for file := to; file != from; file = parent[file] {
+		path = append(path, file)
+	}
+	path = append(path, from)
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// hops turns a chain of files into the imports that connect them
+func (f *pathFinder) hops(files []string) DependencyPath {
+	if len(files) < 2 {
+		return nil
+	}
+	path := make(DependencyPath, 0, len(files)-1)
+	for i := 0; i < len(files)-1; i++ {
+		for _, edge := range f.edges[files[i]] {
+			if edge.to == files[i+1] {
+				path = append(path, PathHop{File: files[i], Import: edge.imp})
+				break
+			}
+		}
+	}
+	return path
+}
+
+// sortPaths orders paths by length, then lexically
+func sortPaths(paths [][]string) {
+	sort.SliceStable(paths, func(i, j int) bool {
+		if len(paths[i]) != len(paths[j]) {
+			return len(paths[i]) < len(paths[j])
+		}
+		return strings.Join(paths[i], "\x00") < strings.Join(paths[j], "\x00")
+	})
+}
+
+func equalPaths(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsPath(paths [][]string, path []string) bool {
+	for _, existing := range paths {
+		if equalPaths(existing, path) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// pythonResolver resolves Python imports the way the interpreter would,
+// searching the source roots of every Python project in the tree
+type pythonResolver struct {
+	roots []string // sys.path-like roots, deepest first
+}
+
+var (
+	// [tool.setuptools.packages.find] where = ["src"]
+	pyprojectWhere = regexp.MustCompile(`(?m)^\s*where\s*=\s*\[([^\]]*)\]`)
+	// [tool.setuptools] package-dir = {"" = "src"}
+	pyprojectPackageDir = regexp.MustCompile(`(?m)^\s*package-dir\s*=\s*\{[^}]*""\s*=\s*"([^"]+)"`)
+	// [tool.poetry] packages = [{ include = "pkg", from = "src" }]
+	pyprojectFrom = regexp.MustCompile(`from\s*=\s*"([^"]+)"`)
+	// [tool.hatch.build.targets.wheel] packages = ["src/pkg"]
+	pyprojectPackages = regexp.MustCompile(`(?m)^\s*packages\s*=\s*\[([^\]]*)\]`)
+	// setup.cfg: package_dir = =src  /  where = src
+	setupCfgPackageDir = regexp.MustCompile(`(?m)^\s*package_dir\s*=\s*(?:\n\s*)?=\s*(\S+)`)
+	setupCfgWhere      = regexp.MustCompile(`(?m)^\s*where\s*=\s*(\S+)`)
+	quotedString       = regexp.MustCompile(`["']([^"']+)["']`)
+)
+
+// discoverPythonRoots collects source roots from the scan root and from
+// every directory holding pyproject.toml, setup.cfg or setup.py
+func discoverPythonRoots(rootPath string, skipDir func(dir string) bool) *pythonResolver {
+	seen := make(map[string]bool)
+	var roots []string
+
+	addRoot := func(dir string) {
+		dir = filepath.Clean(dir)
+		if seen[dir] {
+			return
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			seen[dir] = true
+			roots = append(roots, dir)
+		}
+	}
+
+	addProject := func(dir string) {
+		for _, root := range pythonProjectRoots(dir) {
+			addRoot(root)
+		}
+	}
+
+	addProject(rootPath)
+
+	filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() {
+			if path != rootPath && skipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch info.Name() {
+		case "pyproject.toml", "setup.cfg", "setup.py":
+			addProject(filepath.Dir(path))
+		}
+		return nil
+	})
+
+	sort.SliceStable(roots, func(i, j int) bool {
+		return len(roots[i]) > len(roots[j])
+	})
+
+	return &pythonResolver{roots: roots}
+}
+
+// pythonProjectRoots returns the import roots of one project directory:
+// the directory itself, a src/ layout if present, and any package
+// directories declared in pyproject.toml or setup.cfg
+func pythonProjectRoots(dir string) []string {
+	roots := []string{dir, filepath.Join(dir, "src")}
+
+	if content, err := os.ReadFile(filepath.Join(dir, "pyproject.toml")); err == nil {
+		text := string(content)
+		for _, match := range pyprojectWhere.FindAllStringSubmatch(text, -1) {
+			for _, item := range quotedString.FindAllStringSubmatch(match[1], -1) {
+				roots = append(roots, filepath.Join(dir, item[1]))
+			}
+		}
+		for _, match := range pyprojectPackageDir.FindAllStringSubmatch(text, -1) {
+			roots = append(roots, filepath.Join(dir, match[1]))
+		}
+		for _, match := range pyprojectFrom.FindAllStringSubmatch(text, -1) {
+			roots = append(roots, filepath.Join(dir, match[1]))
+		}
+		for _, match := range pyprojectPackages.FindAllStringSubmatch(text, -1) {
+			for _, item := range quotedString.FindAllStringSubmatch(match[1], -1) {
+				// "src/pkg" is importable from "src"
+				if strings.Contains(item[1], "/") {
+					roots = append(roots, filepath.Join(dir, filepath.Dir(item[1])))
+				}
+			}
+		}
+	}
+
+	if content, err := os.ReadFile(filepath.Join(dir, "setup.cfg")); err == nil {
+		text := string(content)
+		for _, match := range setupCfgPackageDir.FindAllStringSubmatch(text, -1) {
+			roots = append(roots, filepath.Join(dir, match[1]))
+		}
+		for _, match := range setupCfgWhere.FindAllStringSubmatch(text, -1) {
+			roots = append(roots, filepath.Join(dir, match[1]))
+		}
+	}
+
+	return roots
+}
+
+// resolve maps a Python import to the files it loads. `import a.b` and
+// `from a.b import c` depend on module a.b; `from pkg import submodule`
+// additionally (or instead) depends on pkg/submodule.py. Each returned
+// edge carries the symbols it provides.
+func (r *pythonResolver) resolve(fromFile string, imp Import) []Import {
+	var searchDirs []string
+	modulePath := imp.Path
+
+	if strings.HasPrefix(modulePath, ".") {
+		// Relative import: one dot is the current package, each further
+		// dot goes up one level
+		level := len(modulePath) - len(strings.TrimLeft(modulePath, "."))
+		base := filepath.Dir(fromFile)
+		for i := 1; i < level; i++ {
+			base = filepath.Dir(base)
+		}
+		searchDirs = []string{base}
+		modulePath = strings.TrimLeft(modulePath, ".")
+	} else {
+		// The importing script's directory is on sys.path when run directly
+		searchDirs = append(append(searchDirs, r.roots...), filepath.Dir(fromFile))
+	}
+
+	var edges []Import
+	var remaining []string
+
+	for _, dir := range searchDirs {
+		packageDir := filepath.Join(dir, filepath.FromSlash(strings.ReplaceAll(modulePath, ".", "/")))
+
+		// `from pkg import name` where name is a submodule
+		remaining = remaining[:0]
+		for _, symbol := range imp.Symbols {
+			name := strings.Fields(symbol)[0]
+			if name == "*" {
+				remaining = append(remaining, symbol)
+				continue
+			}
+			if submodule := pythonModuleFile(filepath.Join(packageDir, name)); submodule != "" {
+				edge := imp
+				edge.Path = submodule
+				edge.Symbols = []string{symbol}
+				edges = append(edges, edge)
+				continue
+			}
+			remaining = append(remaining, symbol)
+		}
+
+		moduleFile := ""
+		if modulePath != "" {
+			moduleFile = pythonModuleFile(packageDir)
+		} else if init := filepath.Join(packageDir, "__init__.py"); fileExists(init) {
+			// from . import name
+			moduleFile = init
+		}
+
+		if moduleFile != "" && (len(remaining) > 0 || len(edges) == 0) {
+			edge := imp
+			edge.Path = moduleFile
+			edge.Symbols = append([]string{}, remaining...)
+			edges = append(edges, edge)
+		}
+
+		// Keep searching other roots otherwise: portions of a PEP 420
+		// namespace package may live under several of them
+		if len(edges) > 0 {
+			break
+		}
+	}
+
+	return edges
+}
+
+// pythonModuleFile returns the source file for a module path without
+// extension: mod.py, mod.pyi or the package's mod/__init__.py(i)
+func pythonModuleFile(base string) string {
+	for _, candidate := range []string{
+		base + ".py",
+		base + ".pyi",
+		filepath.Join(base, "__init__.py"),
+		filepath.Join(base, "__init__.pyi"),
+	} {
+		if fileExists(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,494 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// queryUsage lists the query subcommands
+const queryUsage = `Usage: dependency-scanner query [flags] <command> [args]
+
+Commands:
+  imports <file>       Files and packages the file imports
+  importers <file>     Files that import the file
+  impact <file>...     Files affected by changing the files, directly or transitively
+  cycles               Circular dependency cycles in import order, with the
+                       imports to remove to break each one
+  dead                 Files that no entry point reaches, with the reason, and
+                       import cycles that only keep each other alive
+  unused               Exported symbols that no other file imports or references
+  path <from> <to>     Why one file depends on another: the shortest import chain,
+                       the -k shortest, or -all chains up to -max-hops
+
+Files may be given as stored in the graph, relative to the current
+directory, or as a unique path suffix such as src/auth.ts.
+
+Flags:
+`
+
+// errNoResult makes a query exit non-zero without an error message, such
+// as when no path exists between two files
+var errNoResult = errors.New("no result")
+
+// runQuery answers a query subcommand from the saved graph and returns the
+// process exit code
+func runQuery(args []string) int {
+	flags := flag.NewFlagSet("query", flag.ExitOnError)
+	graphFlag := flags.String("graph", "", "Graph file to query (default: $DEP_GRAPH_FILE, else the project's configured output)")
+	jsonFlag := flags.Bool("json", false, "Print the result as JSON")
+	depthFlag := flags.Int("depth", 0, "impact: only follow importers this many hops out (0 = unlimited)")
+	kindsFlag := flags.String("kinds", "", "impact: only follow these comma-separated import kinds (e.g. static,require)")
+	skipKindsFlag := flags.String("skip-kinds", "", "impact: never follow these import kinds (e.g. type-only)")
+	languagesFlag := flags.String("languages", "", "impact: only report dependents in these comma-separated languages")
+	kFlag := flags.Int("k", 1, "path: report the k shortest import chains")
+	allFlag := flags.Bool("all", false, "path: report every loop-free import chain up to -max-hops")
+	maxHopsFlag := flags.Int("max-hops", 10, "path: longest chain reported by -all")
+	limitFlag := flags.Int("limit", 100, "path: stop after this many chains with -all")
+	flags.Usage = func() {
+		fmt.Fprint(flags.Output(), queryUsage)
+		flags.PrintDefaults()
+	}
+
+	// Flags may appear before or after the command and its arguments
+	var positional []string
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+
+	if len(positional) == 0 {
+		flags.Usage()
+		return 2
+	}
+	command, operands := positional[0], positional[1:]
+
+	// Argument count per command; -1 allows any number of files
+	want := map[string]int{"imports": 1, "importers": 1, "impact": -1, "cycles": 0, "dead": 0, "unused": 0, "path": 2}
+	count, ok := want[command]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: Unknown query %q\n\n", command)
+		flags.Usage()
+		return 2
+	}
+	if count == -1 && len(operands) == 0 {
+		fmt.Fprintf(os.Stderr, "Error: %s expects at least one file\n\n", command)
+		flags.Usage()
+		return 2
+	}
+	if count >= 0 && len(operands) != count {
+		fmt.Fprintf(os.Stderr, "Error: %s expects %d argument(s), got %d\n\n", command, count, len(operands))
+		flags.Usage()
+		return 2
+	}
+
+	graphPath, err := defaultGraphPath(*graphFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to load project config: %v\n", err)
+		return 1
+	}
+	graph, err := LoadGraph(graphPath)
+	if os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "Error: Graph file not found: %s\n", graphPath)
+		fmt.Fprintf(os.Stderr, "Run the dependency scanner first to generate the graph\n")
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: Failed to load graph: %v\n", err)
+		return 1
+	}
+
+	q := &query{graph: graph, json: *jsonFlag}
+	switch command {
+	case "imports":
+		err = q.imports(operands[0])
+	case "importers":
+		err = q.importers(operands[0])
+	case "impact":
+		err = q.impact(operands, ImpactOptions{
+			MaxDepth:  *depthFlag,
+			Kinds:     splitPatterns(*kindsFlag),
+			SkipKinds: splitPatterns(*skipKindsFlag),
+			Languages: splitPatterns(*languagesFlag),
+		})
+	case "cycles":
+		err = q.cycles()
+	case "dead":
+		err = q.dead()
+	case "unused":
+		err = q.unused()
+	case "path":
+		err = q.path(operands[0], operands[1], pathOptions{
+			k:       *kFlag,
+			all:     *allFlag,
+			maxHops: *maxHopsFlag,
+			limit:   *limitFlag,
+		})
+	}
+
+	if err == errNoResult {
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// defaultGraphPath picks the graph to query: the -graph flag, then
+// $DEP_GRAPH_FILE, then the output configured for the current directory
+func defaultGraphPath(flagValue string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	if env := os.Getenv("DEP_GRAPH_FILE"); env != "" {
+		return env, nil
+	}
+	config, err := loadProjectConfig(".")
+	if err != nil {
+		return "", err
+	}
+	return config.outputPath("."), nil
+}
+
+// query answers questions about a loaded graph
+type query struct {
+	graph *DependencyGraph
+	json  bool
+}
+
+// printJSON writes a query result as indented JSON
+func (q *query) printJSON(value interface{}) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func (q *query) imports(target string) error {
+	path, err := q.graph.findFile(target)
+	if err != nil {
+		return err
+	}
+	node := q.graph.Files[path]
+
+	if q.json {
+		return q.printJSON(struct {
+			File     string
+			Language string
+			Imports  []Import
+		}{path, node.Language, node.Imports})
+	}
+
+	fmt.Printf("File: %s\n", path)
+	fmt.Printf("Language: %s\n", node.Language)
+	fmt.Println()
+	fmt.Println("Imports:")
+	if len(node.Imports) == 0 {
+		fmt.Println("  (none)")
+	}
+	for _, imp := range node.Imports {
+		fmt.Printf("  - %s (line %d, %s)\n", imp.Path, imp.Line, imp.Kind)
+	}
+	return nil
+}
+
+func (q *query) importers(target string) error {
+	path, err := q.graph.findFile(target)
+	if err != nil {
+		return err
+	}
+	importers := append([]string{}, q.graph.Files[path].ImportedBy...)
+	sort.Strings(importers)
+
+	if q.json {
+		return q.printJSON(struct {
+			File       string
+			ImportedBy []string
+		}{path, importers})
+	}
+
+	fmt.Println("Imported by:")
+	printList(importers, "(none)")
+	fmt.Println()
+	fmt.Printf("Total importers: %d\n", len(importers))
+	return nil
+}
+
+func (q *query) impact(targets []string, opts ImpactOptions) error {
+	changed := make([]string, 0, len(targets))
+	for _, target := range targets {
+		path, err := q.graph.findFile(target)
+		if err != nil {
+			return err
+		}
+		changed = append(changed, path)
+	}
+
+	impacted := AnalyzeImpact(q.graph, changed, opts)
+
+	if q.json {
+		if impacted == nil {
+			impacted = []ImpactedFile{}
+		}
+		return q.printJSON(struct {
+			Changed  []string
+			Impacted []ImpactedFile
+		}{changed, impacted})
+	}
+
+	var direct, transitive, tests []string
+	for _, file := range impacted {
+		entry := file.Path
+		if file.IsTest {
+			entry += " [test]"
+			tests = append(tests, file.Path)
+		}
+		if file.Distance == 1 {
+			direct = append(direct, entry)
+		} else {
+			// Show the intermediate files that carry the dependency
+			via := file.Via[1 : len(file.Via)-1]
+			transitive = append(transitive, fmt.Sprintf("%s (distance %d, via %s)", entry, file.Distance, strings.Join(via, " → ")))
+		}
+	}
+
+	fmt.Printf("Impact Analysis for: %s\n", strings.Join(changed, ", "))
+	fmt.Println()
+	fmt.Println("Direct impact:")
+	printList(direct, "(none - no files import this)")
+	fmt.Println()
+	fmt.Println("Transitive impact:")
+	printList(transitive, "(none)")
+	fmt.Println()
+	fmt.Printf("Total files affected: %d (%s)\n", len(impacted), plural(len(tests), "test"))
+	return nil
+}
+
+func (q *query) cycles() error {
+	reports := AnalyzeCycles(q.graph)
+
+	if q.json {
+		if reports == nil {
+			reports = []CycleReport{}
+		}
+		return q.printJSON(struct{ Cycles []CycleReport }{reports})
+	}
+
+	if len(reports) == 0 {
+		fmt.Println("No circular dependencies found")
+		return nil
+	}
+
+	fmt.Printf("Found %d circular dependency cycle(s):\n", len(reports))
+	fmt.Println()
+	for i, report := range reports {
+		if len(report.Files) > len(report.Cycle) {
+			fmt.Printf("Cycle %d (%s, in a tangle of %d files):\n", i+1, plural(len(report.Cycle), "file"), len(report.Files))
+		} else {
+			fmt.Printf("Cycle %d (%s):\n", i+1, plural(len(report.Cycle), "file"))
+		}
+		for _, hop := range report.Cycle {
+			fmt.Printf("  %s:%d imports %s [%s]\n", hop.File, hop.Import.Line, hop.Import.Path, hop.Import.Kind)
+		}
+		fmt.Println("Break it by removing:")
+		for _, hop := range report.Break {
+			fmt.Printf("  - %s:%d import of %s [%s]\n", hop.File, hop.Import.Line, hop.Import.Path, hop.Import.Kind)
+		}
+		fmt.Println()
+	}
+	return nil
+}
+
+func (q *query) dead() error {
+	report := AnalyzeReachability(q.graph)
+
+	if q.json {
+		if report.EntryPoints == nil {
+			report.EntryPoints = []EntryPoint{}
+		}
+		if report.Unreachable == nil {
+			report.Unreachable = []UnreachableFile{}
+		}
+		if report.Cycles == nil {
+			report.Cycles = []UnreachableCycle{}
+		}
+		return q.printJSON(report)
+	}
+
+	if len(report.Unreachable) == 0 {
+		fmt.Println("No dead code found - every file is reachable from an entry point")
+		return nil
+	}
+
+	fmt.Printf("Found %d unreachable file(s):\n", len(report.Unreachable))
+	fmt.Println()
+	for _, file := range report.Unreachable {
+		fmt.Printf("  - %s (%s)\n", file.Path, file.Reason)
+	}
+
+	if len(report.Cycles) > 0 {
+		fmt.Println()
+		fmt.Println("Unreachable import cycles:")
+		for i, cycle := range report.Cycles {
+			fmt.Printf("Cycle %d (%s): %s\n", i+1, plural(len(cycle.Files), "file"), cycle.Reason)
+			printList(cycle.Files, "")
+		}
+	}
+
+	fmt.Println()
+	if report.Inferred {
+		fmt.Println("Note: No entry points were recorded, so files that nothing imports were")
+		fmt.Printf("treated as entry points. List them under \"entryPoints\" in %s.\n", projectConfigFile)
+		return nil
+	}
+
+	// Summarize the entry points the analysis started from by reason
+	counts := make(map[string]int)
+	for _, entry := range report.EntryPoints {
+		counts[entry.Reason]++
+	}
+	reasons := make([]string, 0, len(counts))
+	for reason := range counts {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	fmt.Printf("Reachability from %s:\n", plural(len(report.EntryPoints), "entry point"))
+	for _, reason := range reasons {
+		fmt.Printf("  - %s: %d\n", reason, counts[reason])
+	}
+	return nil
+}
+
+func (q *query) unused() error {
+	if q.json {
+		return q.printJSON(struct{ UnusedExports []UnusedExport }{q.graph.UnusedExports})
+	}
+
+	if len(q.graph.UnusedExports) == 0 {
+		fmt.Println("No unused exports found")
+		return nil
+	}
+
+	files := 0
+	for i, exp := range q.graph.UnusedExports {
+		if i == 0 || exp.Path != q.graph.UnusedExports[i-1].Path {
+			files++
+		}
+	}
+	fmt.Printf("Found %d unused export(s) in %s:\n", len(q.graph.UnusedExports), plural(files, "file"))
+	for i, exp := range q.graph.UnusedExports {
+		if i == 0 || exp.Path != q.graph.UnusedExports[i-1].Path {
+			fmt.Println()
+			fmt.Println(exp.Path)
+		}
+		fmt.Printf("  - %s (%s, line %d)\n", exp.Name, exp.Type, exp.Line)
+	}
+	fmt.Println()
+	fmt.Println("Note: Symbols used only by their own file or package, or from outside the")
+	fmt.Println("project, are listed too. Entry points and unreachable files are not checked.")
+	return nil
+}
+
+// pathOptions selects how many import chains the path query reports
+type pathOptions struct {
+	k       int  // Report the k shortest chains
+	all     bool // Report every chain up to maxHops instead
+	maxHops int
+	limit   int // Stop after this many chains with all
+}
+
+func (q *query) path(fromTarget, toTarget string, opts pathOptions) error {
+	from, err := q.graph.findFile(fromTarget)
+	if err != nil {
+		return err
+	}
+	to, err := q.graph.findFile(toTarget)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return fmt.Errorf("%s and %s are the same file", fromTarget, toTarget)
+	}
+
+	var paths []DependencyPath
+	switch {
+	case opts.all:
+		paths = AllPaths(q.graph, from, to, opts.maxHops, opts.limit)
+	case opts.k > 1:
+		paths = KShortestPaths(q.graph, from, to, opts.k)
+	default:
+		if path := ShortestPath(q.graph, from, to); path != nil {
+			paths = []DependencyPath{path}
+		}
+	}
+
+	if q.json {
+		if paths == nil {
+			paths = []DependencyPath{}
+		}
+		if err := q.printJSON(struct {
+			From  string
+			To    string
+			Paths []DependencyPath
+		}{from, to, paths}); err != nil {
+			return err
+		}
+	} else if len(paths) == 0 {
+		fmt.Printf("%s does not depend on %s\n", from, to)
+	} else {
+		fmt.Printf("Why %s depends on %s:\n", from, to)
+		for i, path := range paths {
+			fmt.Println()
+			if len(paths) > 1 {
+				fmt.Printf("Path %d (%s):\n", i+1, plural(len(path), "hop"))
+			} else {
+				fmt.Printf("Shortest path (%s):\n", plural(len(path), "hop"))
+			}
+			for _, hop := range path {
+				fmt.Printf("  %s:%d imports %s [%s]", hop.File, hop.Import.Line, hop.Import.Path, hop.Import.Kind)
+				if len(hop.Import.Symbols) > 0 {
+					fmt.Printf(" { %s }", strings.Join(hop.Import.Symbols, ", "))
+				}
+				fmt.Println()
+			}
+		}
+	}
+
+	if len(paths) == 0 {
+		return errNoResult
+	}
+	return nil
+}
+
+// printList prints one indented bullet per item, or the placeholder when
+// there are none
+func printList(items []string, none string) {
+	if len(items) == 0 {
+		fmt.Printf("  %s\n", none)
+		return
+	}
+	for _, item := range items {
+		fmt.Printf("  - %s\n", item)
+	}
+}
+
+// plural formats a count with a noun, e.g. "1 hop" or "3 hops"
+func plural(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// EntryPoint is a file that runs without being imported
+type EntryPoint struct {
+	Path   string
+	Reason string
+}
+
+// UnreachableFile is a file that no entry point depends on
+type UnreachableFile struct {
+	Path   string
+	Reason string
+}
+
+// UnreachableCycle is an import cycle that no entry point depends on, so
+// its files keep each other alive without being used
+type UnreachableCycle struct {
+	Files  []string // Sorted
+	Reason string
+}
+
+// ReachabilityReport lists the files that cannot run because no entry
+// point depends on them, directly or transitively
+type ReachabilityReport struct {
+	EntryPoints []EntryPoint
+	Inferred    bool // No entry points were recorded; files nothing imports were used instead
+	Unreachable []UnreachableFile
+	Cycles      []UnreachableCycle
+}
+
+// AnalyzeReachability follows imports from the entry points recorded in
+// FileNode.EntryPoint and reports every file they never reach, with the
+// reason it is unused. Unreachable import cycles are reported as a whole
+// as well, since their files all have importers. Graphs without entry
+// points fall back to treating the files nothing imports as entry points
+// that are themselves reported.
+func AnalyzeReachability(graph *DependencyGraph) ReachabilityReport {
+	var report ReachabilityReport
+	var roots []string
+	for _, path := range graph.SortedPaths() {
+		if reason := graph.Files[path].EntryPoint; reason != "" {
+			report.EntryPoints = append(report.EntryPoints, EntryPoint{Path: path, Reason: reason})
+			roots = append(roots, path)
+		}
+	}
+	if len(roots) == 0 {
+		report.Inferred = true
+		for _, path := range graph.SortedPaths() {
+			if len(graph.Files[path].ImportedBy) == 0 {
+				roots = append(roots, path)
+			}
+		}
+	}
+
+	edges := importEdges(graph)
+	reached := make(map[string]bool, len(graph.Files))
+	queue := append([]string{}, roots...)
+	for _, root := range roots {
+		reached[root] = true
+	}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		next := packageInits(graph, current)
+		for _, edge := range edges[current] {
+			next = append(next, edge.to)
+		}
+		for _, file := range next {
+			if !reached[file] {
+				reached[file] = true
+				queue = append(queue, file)
+			}
+		}
+	}
+
+	// Unreachable cycles, and the cycle each of their files belongs to with
+	// the number of files outside it that import it
+	type cycleInfo struct {
+		files   []string
+		outside int
+	}
+	cycleOf := make(map[string]*cycleInfo)
+	for _, scc := range stronglyConnected(graph, edges) {
+		if len(scc) < 2 || reached[scc[0]] {
+			continue
+		}
+		outside := outsideImporters(graph, scc)
+		reason := "nothing outside the cycle imports it"
+		if len(outside) > 0 {
+			reason = "imported only by unreachable files: " + graph.summarizePaths(outside)
+		}
+		report.Cycles = append(report.Cycles, UnreachableCycle{Files: scc, Reason: reason})
+		cycle := &cycleInfo{files: scc, outside: len(outside)}
+		for _, file := range scc {
+			cycleOf[file] = cycle
+		}
+	}
+
+	for _, path := range graph.SortedPaths() {
+		importers := graph.Files[path].ImportedBy
+		switch {
+		case len(importers) == 0 && (report.Inferred || !reached[path]):
+			report.Unreachable = append(report.Unreachable, UnreachableFile{Path: path, Reason: "nothing imports it"})
+		case reached[path]:
+			continue
+		case cycleOf[path] != nil && cycleOf[path].outside == 0:
+			report.Unreachable = append(report.Unreachable, UnreachableFile{
+				Path:   path,
+				Reason: fmt.Sprintf("imported only from its unreachable import cycle of %s", plural(len(cycleOf[path].files), "file")),
+			})
+		default:
+			sorted := append([]string{}, importers...)
+			sort.Strings(sorted)
+			report.Unreachable = append(report.Unreachable, UnreachableFile{
+				Path:   path,
+				Reason: "imported only by unreachable files: " + graph.summarizePaths(sorted),
+			})
+		}
+	}
+
+	return report
+}
+
+// packageInits returns the __init__ files of the packages enclosing a
+// Python module, which Python runs before the module itself
+func packageInits(graph *DependencyGraph, path string) []string {
+	if graph.Files[path].Language != "python" {
+		return nil
+	}
+	var inits []string
+	for dir := filepath.Dir(path); dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		found := false
+		for _, name := range []string{"__init__.py", "__init__.pyi"} {
+			init := filepath.Join(dir, name)
+			if _, ok := graph.Files[init]; ok && init != path {
+				inits = append(inits, init)
+				found = true
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return inits
+}
+
+// UnreachablePaths returns the paths of the unreachable files, sorted
+func (r ReachabilityReport) UnreachablePaths() []string {
+	paths := make([]string, len(r.Unreachable))
+	for i, file := range r.Unreachable {
+		paths[i] = file.Path
+	}
+	return paths
+}
+
+// outsideImporters returns the sorted importers of a group of files that
+// are not part of it
+func outsideImporters(graph *DependencyGraph, files []string) []string {
+	members := make(map[string]bool, len(files))
+	for _, file := range files {
+		members[file] = true
+	}
+	seen := make(map[string]bool)
+	var outside []string
+	for _, file := range files {
+		for _, importer := range graph.Files[file].ImportedBy {
+			if !members[importer] && !seen[importer] {
+				seen[importer] = true
+				outside = append(outside, importer)
+			}
+		}
+	}
+	sort.Strings(outside)
+	return outside
+}
+
+// summarizePaths lists up to three paths relative to the root
+func (g *DependencyGraph) summarizePaths(paths []string) string {
+	const shown = 3
+	names := make([]string, 0, shown)
+	for i, path := range paths {
+		if i == shown {
+			break
+		}
+		names = append(names, g.relPath(path))
+	}
+	summary := strings.Join(names, ", ")
+	if len(paths) > shown {
+		summary += fmt.Sprintf(" and %d more", len(paths)-shown)
+	}
+	return summary
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"html"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// reportTemplate is the HTML report page. Its script renders the graph
+// data substituted for reportDataMarker, so the page needs no network.
+//
+//go:embed report.html
+var reportTemplate string
+
+const (
+	reportTitleMarker = "{{TITLE}}"
+	reportDataMarker  = "{{DATA}}"
+)
+
+// reportData is the graph as the report page reads it. Files refer to
+// each other by index into Files.
+type reportData struct {
+	Root        string          `json:"root"`
+	Generated   string          `json:"generated"`
+	Languages   []string        `json:"languages"`
+	Files       []reportFile    `json:"files"`
+	Cycles      []reportCycle   `json:"cycles"`
+	DeadCycles  []reportGroup   `json:"deadCycles"`
+	Inferred    bool            `json:"inferred"`
+	Unused      []reportUnused  `json:"unused"`
+	Directories []reportDirStat `json:"directories"`
+}
+
+type reportFile struct {
+	Path      string         `json:"path"`
+	Language  string         `json:"lang"`
+	Package   string         `json:"pkg,omitempty"`
+	Entry     string         `json:"entry,omitempty"`
+	Imports   []reportImport `json:"imports"`
+	External  []string       `json:"external"`  // Imports outside the project
+	Importers []int          `json:"importers"` // Sorted
+	Dead      string         `json:"dead,omitempty"`
+	Cycle     int            `json:"cycle,omitempty"` // 1-based index into Cycles
+}
+
+type reportImport struct {
+	File int    `json:"file"`
+	Line int    `json:"line"`
+	Kind string `json:"kind"`
+}
+
+type reportHop struct {
+	File   int    `json:"file"`
+	Line   int    `json:"line"`
+	Target int    `json:"target"`
+	Kind   string `json:"kind"`
+}
+
+type reportCycle struct {
+	Files []int       `json:"files"`
+	Cycle []reportHop `json:"cycle"`
+	Break []reportHop `json:"break"`
+}
+
+type reportGroup struct {
+	Files  []int  `json:"files"`
+	Reason string `json:"reason"`
+}
+
+type reportUnused struct {
+	File int    `json:"file"`
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Line int    `json:"line"`
+}
+
+// reportDirStat summarizes the files directly inside one directory
+type reportDirStat struct {
+	Dir         string  `json:"dir"`
+	Files       int     `json:"files"`
+	Internal    int     `json:"internal"`    // Imports between files of the directory
+	Efferent    int     `json:"efferent"`    // Imports of files in other directories
+	Afferent    int     `json:"afferent"`    // Imports from files in other directories
+	Instability float64 `json:"instability"` // Efferent / (Afferent + Efferent)
+	Cyclic      int     `json:"cyclic"`      // Files in an import cycle
+	Dead        int     `json:"dead"`
+	Unused      int     `json:"unused"` // Unused exports
+}
+
+// SaveHTML writes a self-contained HTML report of the graph: a searchable
+// file list with each file's imports and importers, the import cycles,
+// dead code, unused exports and per-directory metrics. The graph is
+// embedded in the page, which loads nothing from the network.
+func (g *DependencyGraph) SaveHTML(outputPath string) error {
+	data, err := json.Marshal(g.reportData())
+	if err != nil {
+		return err
+	}
+
+	title := "Dependency report"
+	if g.Root != "" {
+		title += ": " + filepath.Base(g.Root)
+	}
+
+	// json.Marshal escapes <, > and &, so the data can't close the script
+	page := strings.NewReplacer(
+		reportTitleMarker, html.EscapeString(title),
+		reportDataMarker, string(data),
+	).Replace(reportTemplate)
+	return writeFileAtomic(outputPath, []byte(page))
+}
+
+// reportData gathers what the report shows, with paths relative to the root
+func (g *DependencyGraph) reportData() reportData {
+	paths := g.SortedPaths()
+	index := make(map[string]int, len(paths))
+	for i, path := range paths {
+		index[path] = i
+	}
+	indices := func(files []string) []int {
+		list := make([]int, 0, len(files))
+		for _, file := range files {
+			if i, ok := index[file]; ok {
+				list = append(list, i)
+			}
+		}
+		sort.Ints(list)
+		return list
+	}
+	hops := func(path []PathHop) []reportHop {
+		list := make([]reportHop, len(path))
+		for i, hop := range path {
+			list[i] = reportHop{File: index[hop.File], Line: hop.Import.Line, Target: index[hop.Import.Path], Kind: hop.Import.Kind}
+		}
+		return list
+	}
+
+	data := reportData{
+		Root:        g.Root,
+		Generated:   g.LastUpdated.Format(time.RFC3339),
+		Languages:   g.Languages,
+		Files:       make([]reportFile, len(paths)),
+		Cycles:      []reportCycle{},
+		DeadCycles:  []reportGroup{},
+		Unused:      []reportUnused{},
+		Directories: []reportDirStat{},
+	}
+
+	for i, path := range paths {
+		node := g.Files[path]
+		file := reportFile{
+			Path:      g.relPath(path),
+			Language:  node.Language,
+			Package:   node.Package,
+			Entry:     node.EntryPoint,
+			Imports:   []reportImport{},
+			External:  []string{},
+			Importers: indices(node.ImportedBy),
+		}
+		for _, imp := range node.Imports {
+			if target, ok := index[imp.Path]; ok {
+				file.Imports = append(file.Imports, reportImport{File: target, Line: imp.Line, Kind: imp.Kind})
+			} else {
+				file.External = appendMissing(file.External, []string{imp.Path})
+			}
+		}
+		data.Files[i] = file
+	}
+
+	for i, report := range AnalyzeCycles(g) {
+		data.Cycles = append(data.Cycles, reportCycle{
+			Files: indices(report.Files),
+			Cycle: hops(report.Cycle),
+			Break: hops(report.Break),
+		})
+		for _, file := range report.Files {
+			data.Files[index[file]].Cycle = i + 1
+		}
+	}
+
+	reachability := AnalyzeReachability(g)
+	data.Inferred = reachability.Inferred
+	for _, file := range reachability.Unreachable {
+		data.Files[index[file.Path]].Dead = file.Reason
+	}
+	for _, cycle := range reachability.Cycles {
+		data.DeadCycles = append(data.DeadCycles, reportGroup{Files: indices(cycle.Files), Reason: cycle.Reason})
+	}
+
+	for _, exp := range g.UnusedExports {
+		if i, ok := index[exp.Path]; ok {
+			data.Unused = append(data.Unused, reportUnused{File: i, Name: exp.Name, Type: exp.Type, Line: exp.Line})
+		}
+	}
+
+	data.Directories = directoryStats(data)
+	return data
+}
+
+// directoryStats computes coupling metrics for each directory holding files
+func directoryStats(data reportData) []reportDirStat {
+	dirOf := func(i int) string {
+		return filepath.ToSlash(filepath.Dir(data.Files[i].Path))
+	}
+
+	stats := make(map[string]*reportDirStat)
+	stat := func(dir string) *reportDirStat {
+		if stats[dir] == nil {
+			stats[dir] = &reportDirStat{Dir: dir}
+		}
+		return stats[dir]
+	}
+
+	for i, file := range data.Files {
+		s := stat(dirOf(i))
+		s.Files++
+		if file.Cycle != 0 {
+			s.Cyclic++
+		}
+		if file.Dead != "" {
+			s.Dead++
+		}
+
+		seen := make(map[int]bool)
+		for _, imp := range file.Imports {
+			if seen[imp.File] || imp.File == i {
+				continue
+			}
+			seen[imp.File] = true
+			if target := dirOf(imp.File); target == dirOf(i) {
+				s.Internal++
+			} else {
+				s.Efferent++
+				stat(target).Afferent++
+			}
+		}
+	}
+	for _, exp := range data.Unused {
+		stat(dirOf(exp.File)).Unused++
+	}
+
+	list := make([]reportDirStat, 0, len(stats))
+	for _, s := range stats {
+		if coupling := s.Afferent + s.Efferent; coupling > 0 {
+			s.Instability = float64(s.Efferent) / float64(coupling)
+		}
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Dir < list[j].Dir })
+	return list
+}
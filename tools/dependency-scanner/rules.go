@@ -0,0 +1,234 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ArchRule limits what the files matching From may import: never files
+// matching Disallow and, if Allow is set, only files matching Allow or
+// From itself. Patterns are globs relative to the project root, and a
+// pattern also matches the files below it, so "packages/ui" covers
+// "packages/ui/**".
+type ArchRule struct {
+	From     string   `json:"from"`
+	Allow    []string `json:"allow"`
+	Disallow []string `json:"disallow"`
+	Reason   string   `json:"reason"` // Shown with each violation
+}
+
+// ruleNoNewCycles names the noNewCycles setting in violations
+const ruleNoNewCycles = "no new cycles"
+
+// Violation is an import that breaks an architecture rule
+type Violation struct {
+	File   string // Importing file, relative to the root
+	Line   int
+	Import string // Imported file, relative to the root
+	Rule   string // The rule as written by ArchRule.String, or ruleNoNewCycles
+	Detail string // The rule's reason, or the cycle the import closes
+}
+
+func (r ArchRule) validate() error {
+	if r.From == "" {
+		return errors.New(`"from" is required`)
+	}
+	if len(r.Allow) == 0 && len(r.Disallow) == 0 {
+		return errors.New(`set "allow" or "disallow"`)
+	}
+	patterns := append([]string{r.From}, r.Allow...)
+	for _, pattern := range append(patterns, r.Disallow...) {
+		for _, segment := range splitSegments(strings.Trim(pattern, "/")) {
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid pattern %q", pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// String describes the rule, as in "domain/** must not import infra/**"
+func (r ArchRule) String() string {
+	var parts []string
+	if len(r.Allow) > 0 {
+		parts = append(parts, "may only import "+strings.Join(r.Allow, ", "))
+	}
+	if len(r.Disallow) > 0 {
+		parts = append(parts, "must not import "+strings.Join(r.Disallow, ", "))
+	}
+	return r.From + " " + strings.Join(parts, " and ")
+}
+
+// allows reports whether a file matching From may import target
+func (r ArchRule) allows(target string) bool {
+	if matchRulePatterns(r.Disallow, target) {
+		return false
+	}
+	return len(r.Allow) == 0 || matchRulePattern(r.From, target) || matchRulePatterns(r.Allow, target)
+}
+
+// matchRulePattern matches a root-relative path against a rule pattern or
+// the directory it names
+func matchRulePattern(pattern, rel string) bool {
+	return matchGlob(pattern, rel) || matchGlob(strings.TrimSuffix(pattern, "/")+"/**", rel)
+}
+
+func matchRulePatterns(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchRulePattern(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckArchitecture reports the imports between files of the graph that
+// break a rule. Imports of external packages are not checked. A Go import
+// resolves to every file of the package, so it is reported once per
+// package; every other imported file is reported on its own.
+func CheckArchitecture(graph *DependencyGraph, rules []ArchRule) []Violation {
+	edges := importEdges(graph)
+
+	violations := []Violation{}
+	for _, path := range graph.SortedPaths() {
+		from := graph.relPath(path)
+		isGo := graph.Files[path].Language == "go"
+		for _, rule := range rules {
+			if !matchRulePattern(rule.From, from) {
+				continue
+			}
+			// Go packages already reported, by directory: within one
+			// graph a package directory has a single import path
+			reported := make(map[string]bool)
+			for _, edge := range edges[path] {
+				target := graph.relPath(edge.to)
+				if edge.to == path || rule.allows(target) {
+					continue
+				}
+				if isGo {
+					dir := filepath.Dir(edge.to)
+					if reported[dir] {
+						continue
+					}
+					reported[dir] = true
+				}
+				violations = append(violations, Violation{
+					File:   from,
+					Line:   edge.imp.Line,
+					Import: target,
+					Rule:   rule.String(),
+					Detail: rule.Reason,
+				})
+			}
+		}
+	}
+	return violations
+}
+
+// NewCycles reports the imports that close an import cycle missing from
+// the baseline graph, comparing files by their path relative to each
+// graph's root. Imports the baseline doesn't have are blamed; a cycle made
+// only of existing imports, or any cycle without a baseline, is reported
+// at the imports to remove to break it.
+func NewCycles(graph, baseline *DependencyGraph) []Violation {
+	existing := make(map[[2]string]bool) // Imports of the baseline
+	cyclic := make(map[[2]string]bool)   // Imports within a baseline cycle
+	if baseline != nil {
+		edges := importEdges(baseline)
+		for from, list := range edges {
+			for _, edge := range list {
+				existing[[2]string{baseline.relPath(from), baseline.relPath(edge.to)}] = true
+			}
+		}
+		for _, scc := range stronglyConnected(baseline, edges) {
+			for from, list := range componentEdges(scc, edges) {
+				for _, edge := range list {
+					cyclic[[2]string{baseline.relPath(from), baseline.relPath(edge.to)}] = true
+				}
+			}
+		}
+	}
+
+	edges := importEdges(graph)
+	violations := []Violation{}
+	for _, scc := range stronglyConnected(graph, edges) {
+		inner := componentEdges(scc, edges)
+
+		var added []PathHop
+		changed := false
+		for _, from := range scc {
+			for _, edge := range inner[from] {
+				key := [2]string{graph.relPath(from), graph.relPath(edge.to)}
+				if cyclic[key] {
+					continue
+				}
+				changed = true
+				if baseline != nil && !existing[key] {
+					for _, imp := range edge.statements() {
+						added = append(added, PathHop{File: from, Import: imp})
+					}
+				}
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		blame := added
+		if len(blame) == 0 {
+			blame = feedbackArcSet(scc, inner)
+		}
+		for _, hop := range blame {
+			violations = append(violations, Violation{
+				File:   graph.relPath(hop.File),
+				Line:   hop.Import.Line,
+				Import: graph.relPath(hop.Import.Path),
+				Rule:   ruleNoNewCycles,
+				Detail: graph.describeCycle(cycleThrough(inner, hop)),
+			})
+		}
+	}
+	return violations
+}
+
+// cycleThrough finds a shortest cycle that starts with the given import
+func cycleThrough(inner map[string][]importEdge, hop PathHop) DependencyPath {
+	only := make(map[string][]importEdge, len(inner))
+	for file, list := range inner {
+		only[file] = list
+	}
+	only[hop.File] = []importEdge{{to: hop.Import.Path, imp: hop.Import}}
+	return shortestCycle(only, hop.File)
+}
+
+// describeCycle lists the files of a cycle in import order, back to the
+// first
+func (g *DependencyGraph) describeCycle(cycle DependencyPath) string {
+	if len(cycle) == 0 {
+		return ""
+	}
+	files := make([]string, 0, len(cycle)+1)
+	for _, hop := range cycle {
+		files = append(files, g.relPath(hop.File))
+	}
+	files = append(files, files[0])
+	return strings.Join(files, " → ")
+}
+
+// sortViolations orders violations by file, line and imported file
+func sortViolations(violations []Violation) {
+	sort.SliceStable(violations, func(i, j int) bool {
+		a, b := violations[i], violations[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Import < b.Import
+	})
+}
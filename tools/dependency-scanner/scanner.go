@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // Default directories to always exclude
@@ -15,8 +19,8 @@ var defaultExcludeDirs = map[string]bool{
 	".hg":  true,
 
 	// Package managers / dependencies
-	"node_modules": true,
-	"vendor":       true,
+	"node_modules":     true,
+	"vendor":           true,
 	"bower_components": true,
 
 	// Python virtual environments
@@ -24,7 +28,6 @@ var defaultExcludeDirs = map[string]bool{
 	".venv":         true,
 	"virtualenv":    true,
 	".virtualenv":   true,
-	"env":           true,
 	".env":          true,
 	"__pycache__":   true,
 	".pytest_cache": true,
@@ -35,7 +38,6 @@ var defaultExcludeDirs = map[string]bool{
 	"dist":    true,
 	"build":   true,
 	".next":   true,
-	"out":     true,
 	"target":  true,
 	"_build":  true,
 	".output": true,
@@ -47,7 +49,6 @@ var defaultExcludeDirs = map[string]bool{
 	// Data / cache directories (common in projects)
 	".data":  true,
 	".cache": true,
-	"cache":  true,
 
 	// Test coverage
 	"coverage":  true,
@@ -55,75 +56,164 @@ var defaultExcludeDirs = map[string]bool{
 	"htmlcov":   true,
 }
 
+// ScannerOptions configures a Scanner
+type ScannerOptions struct {
+	Verbose     bool
+	Languages   []string            // Grammars to load; empty means ~/.claude/.languages or all
+	EntryPoints []string            // Patterns of files that run without being imported
+	Aliases     map[string][]string // Import aliases resolved like tsconfig paths, relative to the root
+	Excludes    []string            // Additional gitignore-style patterns to exclude
+	Includes    []string            // If set, only files matching one of these patterns are scanned
+	Jobs        int                 // Parser workers; <= 0 means GOMAXPROCS
+	CacheDir    string              // Parse cache directory; empty disables caching
+	Full        bool                // Ignore the parse cache and re-parse every file
+}
+
 // Scanner orchestrates the dependency scanning process
 type Scanner struct {
 	rootPath    string
-	parser      *Parser
+	languages   []string // Grammars each parser worker loads
+	jobs        int
 	graph       *DependencyGraph
 	verbose     bool
-	moduleName  string            // Go module name from go.mod
-	excludeDirs map[string]bool   // Directories to exclude
+	goModules   *goModuleSet           // Go modules from go.mod/go.work (nil if none)
+	ignore      *ignoreMatcher         // .gitignore/.ignore files and -exclude/-include patterns
+	aliases     *tsConfig              // Import aliases from the project config (nil if none)
+	entryPoints []ignoreRule           // Files that are used without being imported
+	tsconfigs   *tsConfigResolver      // tsconfig.json/jsconfig.json path aliases
+	workspaces  *workspaceSet          // npm/pnpm/yarn workspace packages (nil if none)
+	goPackages  *goPackageIndex        // Go package directory contents
+	python      *pythonResolver        // Python source roots
+	cache       *parseCache            // Parse results of the previous run (nil if disabled)
+	entries     map[string]*cacheEntry // Unresolved parse results of the current graph
+	parser      *Parser                // Parser for incremental updates, created lazily
 }
 
 // NewScanner creates a new scanner instance
-func NewScanner(rootPath string, verbose bool, customExcludes []string) (*Scanner, error) {
-	// Load detected languages from .claude/.languages
-	languages, err := loadDetectedLanguages()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load languages: %w", err)
+func NewScanner(rootPath string, opts ScannerOptions) (*Scanner, error) {
+	verbose := opts.Verbose
+
+	jobs := opts.Jobs
+	if jobs <= 0 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+
+	if verbose && len(opts.Excludes) > 0 {
+		printf("Custom exclusions added: %v\n", opts.Excludes)
+	}
+	if verbose && len(opts.Includes) > 0 {
+		printf("Only including: %v\n", opts.Includes)
+	}
+
+	s := &Scanner{
+		rootPath:    rootPath,
+		jobs:        jobs,
+		graph:       NewDependencyGraph(),
+		verbose:     verbose,
+		ignore:      newIgnoreMatcher(rootPath, opts.Excludes, opts.Includes),
+		entryPoints: parseIgnorePatterns(opts.EntryPoints, "entryPoints"),
+		entries:     make(map[string]*cacheEntry),
 	}
 
+	// Languages from the project config, else from ~/.claude/.languages,
+	// else detected from the project's contents
+	languages := opts.Languages
+	if len(languages) == 0 {
+		configured, err := loadDetectedLanguages()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load languages: %w", err)
+		}
+		languages = configured
+	}
+	if len(languages) == 0 {
+		languages = s.detectLanguages()
+	}
+	s.languages = languages
+
 	if verbose {
 		printf("Loaded languages: %v\n", languages)
 	}
 
-	// Create parser with detected languages
-	parser, err := NewParser(languages)
-	if err != nil {
+	// Validate the languages once; each worker creates its own parser
+	if _, err := NewParser(languages); err != nil {
 		return nil, fmt.Errorf("failed to create parser: %w", err)
 	}
 
-	// Try to load Go module name if go.mod exists
-	moduleName := ""
-	goModPath := filepath.Join(rootPath, "go.mod")
-	if content, err := os.ReadFile(goModPath); err == nil {
-		lines := strings.Split(string(content), "\n")
-		for _, line := range lines {
-			line = strings.TrimSpace(line)
-			if strings.HasPrefix(line, "module ") {
-				moduleName = strings.TrimSpace(strings.TrimPrefix(line, "module"))
-				if verbose {
-					printf("Detected Go module: %s\n", moduleName)
-				}
-				break
-			}
+	// Load parse results of the previous run
+	var cache *parseCache
+	if opts.CacheDir != "" {
+		cache = loadParseCache(opts.CacheDir, languages)
+		if opts.Full {
+			cache.Files = make(map[string]*cacheEntry)
+		}
+		if verbose {
+			printf("Parse cache: %s (%d entries)\n", opts.CacheDir, len(cache.Files))
 		}
 	}
 
-	// Merge default exclusions with custom ones
-	excludeDirs := make(map[string]bool)
-	for dir := range defaultExcludeDirs {
-		excludeDirs[dir] = true
+	s.cache = cache
+
+	if len(opts.Aliases) > 0 {
+		s.aliases = &tsConfig{path: projectConfigFile, paths: opts.Aliases, pathsBase: rootPath}
 	}
-	for _, dir := range customExcludes {
-		excludeDirs[dir] = true
+	s.discoverProject()
+
+	return s, nil
+}
+
+// discoverProject (re)reads the project layout that import resolution
+// depends on: Go modules, workspace packages and Python source roots
+func (s *Scanner) discoverProject() {
+	// Discover Go modules, including go.work members and local replaces
+	s.goModules = discoverGoModules(s.rootPath, s.isSkippedDir)
+	if s.verbose && s.goModules != nil {
+		for _, mod := range s.goModules.modules {
+			printf("Detected Go module: %s (%s)\n", mod.Path, mod.Dir)
+		}
 	}
 
-	if verbose && len(customExcludes) > 0 {
-		printf("Custom exclusions added: %v\n", customExcludes)
+	// Discover local packages of an npm/pnpm/yarn workspace
+	s.workspaces = discoverWorkspaces(s.rootPath, s.isSkippedDir)
+	if s.verbose && s.workspaces != nil {
+		printf("Detected workspace packages: %d\n", len(s.workspaces.byName))
 	}
 
-	return &Scanner{
-		rootPath:    rootPath,
-		parser:      parser,
-		graph:       NewDependencyGraph(),
-		verbose:     verbose,
-		moduleName:  moduleName,
-		excludeDirs: excludeDirs,
-	}, nil
+	s.python = discoverPythonRoots(s.rootPath, s.isSkippedDir)
 }
 
-// loadDetectedLanguages reads the .claude/.languages file
+// isProjectManifest reports whether a file name is read by discoverProject
+// or by the tsconfig resolver, so that changing it affects resolution
+func isProjectManifest(name string) bool {
+	switch name {
+	case "go.mod", "go.work", "package.json", "pnpm-workspace.yaml",
+		"pyproject.toml", "setup.cfg", "setup.py", "tsconfig.json", "jsconfig.json":
+		return true
+	}
+	return false
+}
+
+// detectLanguages picks the grammars to load from the project's contents,
+// falling back to defaultLanguages if nothing was recognized
+func (s *Scanner) detectLanguages() []string {
+	detection := detectLanguages(s.rootPath, s.isSkippedDir)
+
+	if s.verbose {
+		for _, lang := range detection.Languages {
+			printf("Detected language: %s\n", detection.describe(lang))
+		}
+		for _, lang := range detection.Unsupported {
+			printf("Detected language without parser support: %s\n", detection.describe(lang))
+		}
+	}
+
+	if len(detection.Languages) == 0 {
+		return defaultLanguages
+	}
+	return detection.Languages
+}
+
+// loadDetectedLanguages reads the ~/.claude/.languages file. Returns nil
+// if it doesn't exist or lists no languages.
 func loadDetectedLanguages() ([]string, error) {
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -132,9 +222,9 @@ func loadDetectedLanguages() ([]string, error) {
 
 	langFile := filepath.Join(homeDir, ".claude", ".languages")
 
-	// If file doesn't exist, use defaults
+	// If file doesn't exist, languages are detected instead
 	if _, err := os.Stat(langFile); os.IsNotExist(err) {
-		return []string{"typescript", "javascript", "go", "python"}, nil
+		return nil, nil
 	}
 
 	content, err := os.ReadFile(langFile)
@@ -152,144 +242,634 @@ func loadDetectedLanguages() ([]string, error) {
 		}
 	}
 
-	if len(languages) == 0 {
-		return []string{"typescript", "javascript", "go", "python"}, nil
-	}
-
 	return languages, nil
 }
 
-// Scan walks the directory tree and builds the dependency graph
+// Scan walks the directory tree and builds the dependency graph. The walk,
+// the parsing and the graph build run as separate pipeline stages: one
+// goroutine walks, s.jobs workers parse with their own tree-sitter parsers,
+// and the results are merged into the graph on the calling goroutine.
 func (s *Scanner) Scan() error {
 	if s.verbose {
-		printf("Scanning directory: %s\n", s.rootPath)
+		printf("Scanning directory: %s (%d workers)\n", s.rootPath, s.jobs)
 	}
 
-	// Walk directory tree
-	err := filepath.Walk(s.rootPath, func(path string, info os.FileInfo, err error) error {
+	parsers := make([]*Parser, s.jobs)
+	for i := range parsers {
+		parser, err := NewParser(s.languages)
 		if err != nil {
-			return err
+			return fmt.Errorf("failed to create parser: %w", err)
 		}
+		parsers[i] = parser
+	}
 
-		// Skip directories
-		if info.IsDir() {
-			name := info.Name()
-			if s.excludeDirs[name] {
-				if s.verbose {
-					printf("Skipping excluded directory: %s\n", path)
+	// Stage 1: walk directory tree
+	files := make(chan walkedFile, 256)
+	var walkErr error
+	go func() {
+		defer close(files)
+		walkErr = s.walkFiles(s.rootPath, s.verbose, func(path string, info os.FileInfo) {
+			files <- walkedFile{path: path, info: info}
+		})
+	}()
+
+	// Stage 2: parse changed files in parallel, reuse cached results
+	results := make(chan parsedFile, 256)
+	var reused, parsed int64
+	var workers sync.WaitGroup
+	for _, parser := range parsers {
+		workers.Add(1)
+		go func(parser *Parser) {
+			defer workers.Done()
+			for file := range files {
+				result, fromCache, err := s.parseFile(parser, file)
+				if err != nil {
+					if s.verbose {
+						printf("Warning: Failed to parse %s: %v\n", file.path, err)
+					}
+					continue // Continue on parse errors
 				}
-				return filepath.SkipDir
+				if fromCache {
+					atomic.AddInt64(&reused, 1)
+				} else {
+					atomic.AddInt64(&parsed, 1)
+				}
+				results <- result
 			}
-			return nil
+		}(parser)
+	}
+	go func() {
+		workers.Wait()
+		close(results)
+	}()
+
+	// Stage 3: merge into the graph
+	entries := make(map[string]*cacheEntry)
+	for result := range results {
+		entries[result.node.Path] = result.entry
+	}
+
+	if walkErr != nil {
+		return walkErr
+	}
+
+	s.entries = entries
+	s.rebuild()
+	s.saveCache()
+
+	if s.verbose {
+		printf("Scan complete: %d files processed (%d parsed, %d from cache)\n", len(s.graph.Files), parsed, reused)
+	}
+
+	return nil
+}
+
+// walkedFile is a supported source file found by the walk stage
+type walkedFile struct {
+	path string
+	info os.FileInfo
+}
+
+// parsedFile is the outcome of the parse stage for one file
+type parsedFile struct {
+	node  *FileNode
+	entry *cacheEntry
+}
+
+// parseFile returns the cached parse result for an unchanged file or
+// parses it. Unchanged means same size and mtime, or same content hash.
+func (s *Scanner) parseFile(parser *Parser, file walkedFile) (parsedFile, bool, error) {
+	if node := s.cache.lookup(file.path, file.info); node != nil {
+		return parsedFile{node: node, entry: s.cache.Files[file.path]}, true, nil
+	}
+
+	content, err := os.ReadFile(file.path)
+	if err != nil {
+		return parsedFile{}, false, err
+	}
+	hash := hashContent(content)
+
+	if node := s.cache.lookupHash(file.path, hash); node != nil {
+		return parsedFile{node: node, entry: newCacheEntry(node, file.info, hash)}, true, nil
+	}
+
+	if s.verbose {
+		printf("Parsing: %s\n", file.path)
+	}
+
+	node, err := parser.ParseContent(file.path, content)
+	if err != nil {
+		return parsedFile{}, false, err
+	}
+	return parsedFile{node: node, entry: newCacheEntry(node, file.info, hash)}, false, nil
+}
+
+// Update re-parses or removes the given files and directories and rebuilds
+// the graph. A directory stands for every file below it; paths that no
+// longer exist are dropped from the graph together with their contents.
+// It returns the graph files that were added, modified or removed, or nil
+// if nothing affecting the graph changed.
+func (s *Scanner) Update(paths []string) ([]string, error) {
+	if s.parser == nil {
+		parser, err := NewParser(s.languages)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create parser: %w", err)
 		}
+		s.parser = parser
+	}
+
+	changed := make(map[string]bool)
+	manifestChanged := false
 
-		// Check if file is supported
-		if s.isSupportedFile(path) {
+	for _, path := range paths {
+		if isIgnoreFile(filepath.Base(path)) {
+			// Exclusions may have changed anywhere below: re-check the tree
+			s.ignore.reset()
+			paths = append(paths, s.rootPath)
+			break
+		}
+	}
+
+	update := func(path string, info os.FileInfo) {
+		result, fromCache, err := s.parseFile(s.parser, walkedFile{path: path, info: info})
+		if err != nil {
 			if s.verbose {
-				printf("Parsing: %s\n", path)
+				printf("Warning: Failed to parse %s: %v\n", path, err)
 			}
+			return
+		}
+		if _, known := s.entries[path]; !known || !fromCache {
+			changed[path] = true
+		}
+		s.entries[path] = result.entry
+	}
 
-			node, err := s.parser.Parse(path)
-			if err != nil {
-				if s.verbose {
-					printf("Warning: Failed to parse %s: %v\n", path, err)
+	remove := func(path string) {
+		if _, known := s.entries[path]; known {
+			delete(s.entries, path)
+			changed[path] = true
+		}
+	}
+
+	for _, path := range paths {
+		if isProjectManifest(filepath.Base(path)) {
+			manifestChanged = true
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			// Drop files of removed directories, files that vanished from
+			// a directory before their own event arrived and files that
+			// are excluded now
+			prefix := path + string(filepath.Separator)
+			for known := range s.entries {
+				if known == path || strings.HasPrefix(known, prefix) {
+					if _, err := os.Stat(known); err != nil || s.explainSkip(known) != nil {
+						remove(known)
+					}
 				}
-				return nil // Continue on parse errors
 			}
+		}
+		if err != nil {
+			continue
+		}
+
+		if info.IsDir() {
+			if s.isExcludedDir(path) {
+				continue
+			}
+			if err := s.walkFiles(path, false, update); err != nil && s.verbose {
+				printf("Warning: Failed to walk %s: %v\n", path, err)
+			}
+			continue
+		}
+
+		if s.explainSkip(path) == nil {
+			update(path, info)
+		} else {
+			remove(path)
+		}
+	}
+
+	if len(changed) == 0 && !manifestChanged {
+		return nil, nil
+	}
+
+	if manifestChanged {
+		s.discoverProject()
+	}
+	s.rebuild()
+	s.saveCache()
+
+	files := make([]string, 0, len(changed))
+	for path := range changed {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// rebuild recreates the graph from the parse results, re-resolving every
+// import against the current state of the file system
+func (s *Scanner) rebuild() {
+	s.graph = NewDependencyGraph()
+	s.graph.Languages = s.sortedLanguages()
+	s.graph.Root = s.rootPath
+	s.tsconfigs = newTSConfigResolver()
+	s.goPackages = newGoPackageIndex()
+
+	for path, entry := range s.entries {
+		s.addNode(entry.node(path))
+	}
+
+	s.buildGraph()
+}
+
+// saveCache persists the current parse results if caching is enabled
+func (s *Scanner) saveCache() {
+	if s.cache == nil {
+		return
+	}
+	if err := s.cache.save(s.entries); err != nil && s.verbose {
+		printf("Warning: Failed to save parse cache: %v\n", err)
+	}
+}
+
+// walkFiles calls visit for every supported file below root that is not
+// excluded. Files that disappear during the walk are skipped.
+func (s *Scanner) walkFiles(root string, logSkips bool, visit func(path string, info os.FileInfo)) error {
+	return s.walkTree(root, logSkips, func(path string, info os.FileInfo) {
+		if s.isSupportedFile(path) && s.skipFile(path) == nil {
+			visit(path, info)
+		}
+	})
+}
+
+// walkTree calls visit for every file below root outside excluded
+// directories
+func (s *Scanner) walkTree(root string, logSkips bool, visit func(path string, info os.FileInfo)) error {
+	return filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path != root && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
 
-			s.graph.Files[path] = node
+		// Skip directories
+		if info.IsDir() {
+			if reason := s.skipDir(path); reason != nil {
+				if logSkips {
+					printf("Skipping excluded directory: %s (%s)\n", path, reason.Source)
+				}
+				return filepath.SkipDir
+			}
+			return nil
 		}
 
+		visit(path, info)
 		return nil
 	})
+}
+
+// skipDir returns why a directory is excluded, ignoring its parents.
+// Ignore rules come first so that a negation such as `!build/` can bring
+// back a directory excluded by default.
+func (s *Scanner) skipDir(dir string) *SkipReason {
+	if dir == s.rootPath {
+		return nil
+	}
+	if rule := s.ignore.match(dir, true); rule != nil {
+		if rule.negate {
+			return nil
+		}
+		return rule.reason(dir)
+	}
+
+	name := filepath.Base(dir)
+	if defaultExcludeDirs[name] {
+		return &SkipReason{Path: dir, Rule: name, Source: "default exclusions"}
+	}
+	// Virtual environments are recognized by content, whatever their name
+	if fileExists(filepath.Join(dir, "pyvenv.cfg")) {
+		return &SkipReason{Path: dir, Rule: "pyvenv.cfg", Source: "virtualenv detection"}
+	}
+	return nil
+}
+
+// skipFile returns why a file is excluded, ignoring its parents
+func (s *Scanner) skipFile(path string) *SkipReason {
+	if rule := s.ignore.match(path, false); rule != nil && !rule.negate {
+		return rule.reason(path)
+	}
+	if !s.ignore.included(path) {
+		return &SkipReason{Path: path, Rule: s.ignore.includePatterns(), Source: "-include (no match)"}
+	}
+	return nil
+}
+
+// explainSkip returns why a path is not part of the graph, checking each
+// parent directory from the root down, or nil if it is scanned
+func (s *Scanner) explainSkip(path string) *SkipReason {
+	rel, err := filepath.Rel(s.rootPath, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return &SkipReason{Path: path, Rule: s.rootPath, Source: "scan root (outside)"}
+	}
+	if rel == "." {
+		return nil
+	}
+
+	// Paths are rebuilt from the root so they match those of the walk
+	dir := s.rootPath
+	segments := strings.Split(rel, string(filepath.Separator))
+	for _, segment := range segments[:len(segments)-1] {
+		dir = filepath.Join(dir, segment)
+		if reason := s.skipDir(dir); reason != nil {
+			return reason
+		}
+	}
+	path = filepath.Join(dir, segments[len(segments)-1])
+
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return s.skipDir(path)
+	}
+	if !s.isSupportedFile(path) {
+		if lang := fileLanguage(path); lang != "" {
+			if _, parsable := grammars[lang]; parsable {
+				return &SkipReason{Path: path, Rule: lang, Source: "languages not loaded"}
+			}
+		}
+		return &SkipReason{Path: path, Rule: filepath.Ext(path), Source: "supported file extensions"}
+	}
+	return s.skipFile(path)
+}
 
+// ExplainSkip reports which rule excludes path from the scan, or nil if
+// the path is scanned
+func (s *Scanner) ExplainSkip(path string) *SkipReason {
+	root, err := filepath.Abs(s.rootPath)
 	if err != nil {
-		return err
+		return s.explainSkip(path)
 	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return s.explainSkip(path)
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return s.explainSkip(path)
+	}
+	return s.explainSkip(filepath.Join(s.rootPath, rel))
+}
+
+// isSkippedDir reports whether a directory is excluded, ignoring its parents
+func (s *Scanner) isSkippedDir(dir string) bool {
+	return s.skipDir(dir) != nil
+}
+
+// isExcludedDir reports whether dir is, or lies inside, an excluded
+// directory of the scanned tree. Directories outside the tree are excluded.
+func (s *Scanner) isExcludedDir(dir string) bool {
+	return s.explainSkip(dir) != nil
+}
 
+// addNode records a parsed file in the graph along with the workspace or
+// Go package it belongs to
+func (s *Scanner) addNode(node *FileNode) {
+	if s.workspaces != nil {
+		if wp := s.workspaces.packageFor(node.Path); wp != nil {
+			node.Package = wp.Name
+		}
+	}
+	if node.Language == "go" {
+		node.Package = s.goImportPath(filepath.Dir(node.Path))
+	}
+
+	s.graph.Files[node.Path] = node
+}
+
+// buildGraph derives reverse edges, cycles, entry points, dead code and
+// unused exports from the parsed files
+func (s *Scanner) buildGraph() {
 	// Build reverse dependencies
 	s.buildReverseImports()
 
 	// Detect circular dependencies
 	s.graph.Circular = DetectCircularDependencies(s.graph)
 
-	// Detect dead code
-	s.graph.DeadCode = DetectDeadCode(s.graph)
+	// Find files no entry point depends on
+	s.markEntryPoints()
+	s.graph.DeadCode = AnalyzeReachability(s.graph).UnreachablePaths()
 
-	if s.verbose {
-		printf("Scan complete: %d files processed\n", len(s.graph.Files))
-	}
+	// Find public symbols nothing else uses
+	s.graph.UnusedExports = DetectUnusedExports(s.graph)
+}
 
-	return nil
+// isEntryPoint reports whether a file matches a configured entry point
+func (s *Scanner) isEntryPoint(path string) bool {
+	rel, ok := s.ignore.relPath(path)
+	if !ok {
+		return false
+	}
+	for _, rule := range s.entryPoints {
+		if rule.matches(rel, false) {
+			return true
+		}
+	}
+	return false
 }
 
-// isSupportedFile checks if a file should be parsed
+// isSupportedFile checks if a file should be parsed, by its extension or,
+// for extensionless scripts, its shebang
 func (s *Scanner) isSupportedFile(path string) bool {
-	ext := filepath.Ext(path)
-	supportedExts := map[string]bool{
-		".ts":   true,
-		".tsx":  true,
-		".js":   true,
-		".jsx":  true,
-		".mjs":  true,
-		".cjs":  true,
-		".go":   true,
-		".py":   true,
-		".pyi":  true,
-	}
-	return supportedExts[ext]
-}
-
-// buildReverseImports populates the ImportedBy field for each file
+	lang := fileLanguage(path)
+	if lang == "" {
+		return false
+	}
+	// .tsx falls back to the TypeScript grammar
+	if lang == "tsx" && s.hasLanguage("typescript") {
+		return true
+	}
+	return s.hasLanguage(lang)
+}
+
+// sortedLanguages returns the loaded languages in lexical order
+func (s *Scanner) sortedLanguages() []string {
+	languages := append([]string{}, s.languages...)
+	sort.Strings(languages)
+	return languages
+}
+
+// hasLanguage reports whether the grammar for lang is loaded
+func (s *Scanner) hasLanguage(lang string) bool {
+	for _, loaded := range s.languages {
+		if loaded == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// buildReverseImports resolves imports to files and populates the
+// ImportedBy field for each file. One import may resolve to several files:
+// a Go import depends on every file of the package, and a Python
+// `from pkg import a, b` may load submodules pkg/a.py and pkg/b.py.
 func (s *Scanner) buildReverseImports() {
-	for filePath, node := range s.graph.Files {
-		for i, imp := range node.Imports {
-			resolvedPath := s.resolveImport(filePath, imp.Path)
-			if resolvedPath == "" {
+	for _, filePath := range s.graph.SortedPaths() {
+		node := s.graph.Files[filePath]
+		resolvedImports := make([]Import, 0, len(node.Imports))
+
+		for _, imp := range node.Imports {
+			edges := s.resolveEdges(filePath, node.Language, imp)
+			if len(edges) == 0 {
+				// Keep external imports with their original specifier
+				resolvedImports = append(resolvedImports, imp)
 				continue
 			}
 
-			node.Imports[i].Path = resolvedPath
+			for _, edge := range edges {
+				resolvedImports = append(resolvedImports, edge)
 
-			if importedNode, exists := s.graph.Files[resolvedPath]; exists {
-				importedNode.ImportedBy = append(importedNode.ImportedBy, filePath)
+				importedNode, exists := s.graph.Files[edge.Path]
+				if !exists {
+					continue
+				}
+				// Several imports of the same file count as one importer
+				if n := len(importedNode.ImportedBy); n == 0 || importedNode.ImportedBy[n-1] != filePath {
+					importedNode.ImportedBy = append(importedNode.ImportedBy, filePath)
+				}
 			}
 		}
+
+		node.Imports = resolvedImports
 	}
 }
 
-func (s *Scanner) resolveImport(fromFile, importPath string) string {
-	if s.moduleName != "" && strings.HasPrefix(importPath, s.moduleName+"/") {
-		relPath := strings.TrimPrefix(importPath, s.moduleName+"/")
-		packageDir := filepath.Join(s.rootPath, relPath)
-
-		if files, err := filepath.Glob(filepath.Join(packageDir, "*.go")); err == nil && len(files) > 0 {
-			return files[0]
+// resolveEdges returns the import with Path replaced by each file it
+// resolves to, or nothing if it points outside the project
+func (s *Scanner) resolveEdges(fromFile, language string, imp Import) []Import {
+	var targets []string
+
+	switch language {
+	case "python":
+		return s.python.resolve(fromFile, imp)
+	case "go":
+		targets = s.resolveGoImport(fromFile, imp.Path)
+	default:
+		if resolvedPath := s.resolveImport(fromFile, imp.Path); resolvedPath != "" {
+			targets = []string{resolvedPath}
 		}
+	}
 
-		if _, err := os.Stat(packageDir + ".go"); err == nil {
-			return packageDir + ".go"
-		}
+	edges := make([]Import, 0, len(targets))
+	for _, target := range targets {
+		edge := imp
+		edge.Path = target
+		edges = append(edges, edge)
+	}
+	return edges
+}
 
-		return ""
+// resolveGoImport maps a Go import path to the files of that package when
+// it belongs to one of the repository's modules. Standard library and
+// third-party imports resolve to nothing.
+func (s *Scanner) resolveGoImport(fromFile, importPath string) []string {
+	if s.goModules == nil {
+		return nil
 	}
 
-	if !strings.HasPrefix(importPath, ".") && !strings.HasPrefix(importPath, "/") {
+	packageDir := s.goModules.packageDir(fromFile, importPath)
+	if packageDir == "" {
+		return nil
+	}
+	return s.goPackages.packageFiles(packageDir)
+}
+
+// goImportPath returns the import path of the Go package in dir
+func (s *Scanner) goImportPath(dir string) string {
+	if s.goModules == nil {
 		return ""
 	}
+	return s.goModules.importPath(dir)
+}
 
+// resolveImport maps a JS/TS import to a single file
+func (s *Scanner) resolveImport(fromFile, importPath string) string {
 	fromDir := filepath.Dir(fromFile)
-	resolved := filepath.Join(fromDir, importPath)
 
+	if strings.HasPrefix(importPath, ".") || strings.HasPrefix(importPath, "/") {
+		return resolveFile(filepath.Join(fromDir, importPath))
+	}
+
+	// Bare specifiers in JS/TS may be aliases from the project config or
+	// tsconfig/jsconfig paths
+	if isJSFile(fromFile) {
+		if s.aliases != nil {
+			for _, candidate := range s.aliases.candidates(importPath) {
+				if resolved := resolveFile(candidate); resolved != "" {
+					return resolved
+				}
+			}
+		}
+
+		if cfg := s.tsconfigs.forDir(fromDir); cfg != nil {
+			for _, candidate := range cfg.candidates(importPath) {
+				if resolved := resolveFile(candidate); resolved != "" {
+					return resolved
+				}
+			}
+		}
+
+		// Bare package names of local workspace packages
+		if s.workspaces != nil {
+			fallback := ""
+			for _, candidate := range s.workspaces.candidates(importPath) {
+				resolved := resolveFile(candidate)
+				if resolved == "" {
+					continue
+				}
+				// Prefer scanned sources over build output such as dist/
+				if _, scanned := s.graph.Files[resolved]; scanned {
+					return resolved
+				}
+				if fallback == "" {
+					fallback = resolved
+				}
+			}
+			return fallback
+		}
+	}
+
+	return ""
+}
+
+// resolveFile maps an extensionless or directory import to a source file
+func resolveFile(resolved string) string {
 	// Try different extensions
-	extensions := []string{"", ".ts", ".tsx", ".js", ".jsx", ".go", ".py"}
+	extensions := []string{"", ".ts", ".tsx", ".d.ts", ".js", ".jsx", ".go", ".py"}
 	for _, ext := range extensions {
 		testPath := resolved + ext
-		if _, err := os.Stat(testPath); err == nil {
+		if info, err := os.Stat(testPath); err == nil && !info.IsDir() {
 			return testPath
 		}
 	}
 
+	// ESM-style TypeScript imports name the compiled .js file
+	jsToTS := map[string][]string{
+		".js":  {".ts", ".tsx"},
+		".jsx": {".tsx"},
+		".mjs": {".mts"},
+		".cjs": {".cts"},
+	}
+	if sources, ok := jsToTS[filepath.Ext(resolved)]; ok {
+		stem := strings.TrimSuffix(resolved, filepath.Ext(resolved))
+		for _, ext := range sources {
+			if _, err := os.Stat(stem + ext); err == nil {
+				return stem + ext
+			}
+		}
+	}
+
 	// Try index files
 	for _, indexFile := range []string{"index.ts", "index.tsx", "index.js", "index.jsx"} {
 		testPath := filepath.Join(resolved, indexFile)
@@ -301,6 +881,15 @@ func (s *Scanner) resolveImport(fromFile, importPath string) string {
 	return ""
 }
 
+// isJSFile reports whether a file is JavaScript or TypeScript source
+func isJSFile(path string) bool {
+	switch filepath.Ext(path) {
+	case ".ts", ".tsx", ".mts", ".cts", ".js", ".jsx", ".mjs", ".cjs":
+		return true
+	}
+	return false
+}
+
 // GetGraph returns the built dependency graph
 func (s *Scanner) GetGraph() *DependencyGraph {
 	return s.graph
@@ -0,0 +1,434 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// toonVersion is the TOON graph format written by SaveTOON. Version 1
+// files have no version header and store values unescaped; version 2 files
+// list public exports only and no import uses.
+const toonVersion = 3
+
+// toonEscaper percent-encodes the characters that delimit TOON values:
+// "," separates list entries, ":" separates fields, ">" separates cycle
+// members and ";" separates import symbols. Ordinary paths are unchanged.
+var toonEscaper = strings.NewReplacer(
+	"%", "%25",
+	",", "%2C",
+	":", "%3A",
+	">", "%3E",
+	";", "%3B",
+	"\n", "%0A",
+	"\r", "%0D",
+)
+
+var toonUnescaper = strings.NewReplacer(
+	"%25", "%",
+	"%2C", ",",
+	"%3A", ":",
+	"%3E", ">",
+	"%3B", ";",
+	"%0A", "\n",
+	"%0D", "\r",
+)
+
+func escapeTOON(value string) string {
+	return toonEscaper.Replace(value)
+}
+
+// escapeTOONList escapes each value and joins them with sep
+func escapeTOONList(values []string, sep string) string {
+	escaped := make([]string, len(values))
+	for i, value := range values {
+		escaped[i] = escapeTOON(value)
+	}
+	return strings.Join(escaped, sep)
+}
+
+// SaveTOON writes the graph in TOON format:
+//
+//	META:version=3
+//	FILE:<path>
+//	LANG:<language>
+//	PKG:<package>                                  (if any)
+//	ENTRY:<reason>                                 (entry points only)
+//	IMPORTS:<path>:<line>:<kind>[:<sym>;<sym>[:<use>;<use>]],...
+//	EXPORTS:<name>:<type>:<line>[:default][:package|:private],...
+//	IMPORTEDBY:<path>,...
+//	---
+//	CIRCULAR:<path>><path>>...                    (each file imports the next)
+//	---
+//	DEADCODE:<path>
+//	---
+//	UNUSED:<path>:<name>:<type>:<line>            (exports no other file uses)
+//	---
+//	META:lastUpdated=<RFC3339>
+//	META:languages=<language>,...
+//	META:root=<scanned directory>
+//
+// Values are escaped with escapeTOON, so any path survives LoadTOON, and
+// every field of the graph is written, so a loaded graph answers queries
+// like a fresh scan.
+func (g *DependencyGraph) SaveTOON(outputPath string) error {
+	var builder strings.Builder
+
+	fmt.Fprintf(&builder, "META:version=%d\n", toonVersion)
+
+	for _, filePath := range g.SortedPaths() {
+		node := g.Files[filePath]
+		builder.WriteString("FILE:")
+		builder.WriteString(escapeTOON(filePath))
+		builder.WriteString("\n")
+
+		builder.WriteString("LANG:")
+		builder.WriteString(escapeTOON(node.Language))
+		builder.WriteString("\n")
+
+		if node.Package != "" {
+			builder.WriteString("PKG:")
+			builder.WriteString(escapeTOON(node.Package))
+			builder.WriteString("\n")
+		}
+
+		if node.EntryPoint != "" {
+			builder.WriteString("ENTRY:")
+			builder.WriteString(escapeTOON(node.EntryPoint))
+			builder.WriteString("\n")
+		}
+
+		builder.WriteString("IMPORTS:")
+		imports := make([]string, len(node.Imports))
+		for i, imp := range node.Imports {
+			imports[i] = fmt.Sprintf("%s:%d:%s", escapeTOON(imp.Path), imp.Line, escapeTOON(imp.Kind))
+			if len(imp.Symbols) > 0 || len(imp.Uses) > 0 {
+				imports[i] += ":" + escapeTOONList(imp.Symbols, ";")
+			}
+			if len(imp.Uses) > 0 {
+				imports[i] += ":" + escapeTOONList(imp.Uses, ";")
+			}
+		}
+		builder.WriteString(strings.Join(imports, ","))
+		builder.WriteString("\n")
+
+		builder.WriteString("EXPORTS:")
+		exports := make([]string, len(node.Exports))
+		for i, exp := range node.Exports {
+			exports[i] = fmt.Sprintf("%s:%s:%d", escapeTOON(exp.Name), escapeTOON(exp.Type), exp.Line)
+			if exp.IsDefault {
+				exports[i] += ":default"
+			}
+			// Public symbols, the file's exported surface, carry no flag
+			if !exp.IsPublic() {
+				exports[i] += ":" + escapeTOON(exp.Visibility)
+			}
+		}
+		builder.WriteString(strings.Join(exports, ","))
+		builder.WriteString("\n")
+
+		builder.WriteString("IMPORTEDBY:")
+		builder.WriteString(escapeTOONList(node.ImportedBy, ","))
+		builder.WriteString("\n")
+
+		builder.WriteString("---\n")
+	}
+
+	if len(g.Circular) > 0 {
+		for _, cycle := range g.Circular {
+			builder.WriteString("CIRCULAR:")
+			builder.WriteString(escapeTOONList(cycle, ">"))
+			builder.WriteString("\n")
+		}
+		builder.WriteString("---\n")
+	}
+
+	if len(g.DeadCode) > 0 {
+		for _, deadFile := range g.DeadCode {
+			builder.WriteString("DEADCODE:")
+			builder.WriteString(escapeTOON(deadFile))
+			builder.WriteString("\n")
+		}
+		builder.WriteString("---\n")
+	}
+
+	if len(g.UnusedExports) > 0 {
+		for _, exp := range g.UnusedExports {
+			fmt.Fprintf(&builder, "UNUSED:%s:%s:%s:%d\n", escapeTOON(exp.Path), escapeTOON(exp.Name), escapeTOON(exp.Type), exp.Line)
+		}
+		builder.WriteString("---\n")
+	}
+
+	builder.WriteString("META:lastUpdated=")
+	builder.WriteString(g.LastUpdated.Format(time.RFC3339))
+	builder.WriteString("\n")
+
+	if len(g.Languages) > 0 {
+		builder.WriteString("META:languages=")
+		builder.WriteString(strings.Join(g.Languages, ","))
+		builder.WriteString("\n")
+	}
+
+	if g.Root != "" {
+		builder.WriteString("META:root=")
+		builder.WriteString(escapeTOON(g.Root))
+		builder.WriteString("\n")
+	}
+
+	return writeFileAtomic(outputPath, []byte(builder.String()))
+}
+
+// LoadTOON reads a graph written by SaveTOON, including unversioned graphs
+// from earlier scanners
+func LoadTOON(path string) (*DependencyGraph, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := parseTOON(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return graph, nil
+}
+
+// toonReader decodes TOON records of one format version
+type toonReader struct {
+	version int
+	graph   *DependencyGraph
+	node    *FileNode // FILE record being read, nil between records
+}
+
+// parseTOON decodes the records of a TOON graph
+func parseTOON(content string) (*DependencyGraph, error) {
+	r := &toonReader{version: 1, graph: NewDependencyGraph()}
+
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if line == "" {
+			continue
+		}
+		if err := r.readLine(line, i == 0); err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+	}
+
+	return r.graph, nil
+}
+
+func (r *toonReader) readLine(line string, first bool) error {
+	if line == "---" {
+		r.node = nil
+		return nil
+	}
+
+	key, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return fmt.Errorf("malformed record %q", line)
+	}
+
+	switch key {
+	case "FILE":
+		path := r.unescape(value)
+		r.node = &FileNode{Path: path, Imports: []Import{}, Exports: []Export{}, ImportedBy: []string{}}
+		r.graph.Files[path] = r.node
+	case "CIRCULAR":
+		r.graph.Circular = append(r.graph.Circular, r.unescapeList(value, ">"))
+	case "DEADCODE":
+		r.graph.DeadCode = append(r.graph.DeadCode, r.unescape(value))
+	case "UNUSED":
+		return r.readUnused(value)
+	case "META":
+		return r.readMeta(value, first)
+	case "LANG", "PKG", "ENTRY", "IMPORTS", "EXPORTS", "IMPORTEDBY":
+		if r.node == nil {
+			return fmt.Errorf("%s outside a FILE record", key)
+		}
+		return r.readField(key, value)
+	}
+	// Unknown records are skipped so newer additions stay readable
+	return nil
+}
+
+func (r *toonReader) readMeta(value string, first bool) error {
+	name, metaValue, _ := strings.Cut(value, "=")
+	switch name {
+	case "version":
+		version, err := strconv.Atoi(metaValue)
+		if err != nil || !first {
+			return fmt.Errorf("malformed version header %q", value)
+		}
+		if version > toonVersion {
+			return fmt.Errorf("graph format version %d is newer than this scanner supports (%d); rebuild the graph or update the scanner", version, toonVersion)
+		}
+		r.version = version
+	case "lastUpdated":
+		if t, err := time.Parse(time.RFC3339, metaValue); err == nil {
+			r.graph.LastUpdated = t
+		}
+	case "root":
+		r.graph.Root = r.unescape(metaValue)
+	case "languages":
+		if metaValue != "" {
+			r.graph.Languages = strings.Split(metaValue, ",")
+		}
+	}
+	return nil
+}
+
+// readField fills the current node from one field of its FILE record
+func (r *toonReader) readField(key, value string) error {
+	node := r.node
+	switch key {
+	case "LANG":
+		node.Language = r.unescape(value)
+	case "PKG":
+		node.Package = r.unescape(value)
+	case "ENTRY":
+		node.EntryPoint = r.unescape(value)
+	case "IMPORTEDBY":
+		node.ImportedBy = r.unescapeList(value, ",")
+	case "IMPORTS":
+		for _, entry := range splitNonEmpty(value, ",") {
+			imp, err := r.readImport(entry)
+			if err != nil {
+				return err
+			}
+			node.Imports = append(node.Imports, imp)
+		}
+	case "EXPORTS":
+		for _, entry := range splitNonEmpty(value, ",") {
+			exp, err := r.readExport(entry)
+			if err != nil {
+				return err
+			}
+			node.Exports = append(node.Exports, exp)
+		}
+	}
+	return nil
+}
+
+// readImport decodes path:line:kind[:symbols[:uses]]. Version 1 graphs may also
+// hold the path:line form of the first scanners, read as static imports.
+func (r *toonReader) readImport(entry string) (Import, error) {
+	fields := strings.Split(entry, ":")
+	if r.version == 1 {
+		// Unescaped paths may contain colons, so the line is the last
+		// number among the final three fields and the path precedes it
+		for n := 1; n <= 3 && n < len(fields); n++ {
+			if _, err := strconv.Atoi(fields[len(fields)-n]); err == nil {
+				i := len(fields) - n
+				fields = append([]string{strings.Join(fields[:i], ":")}, fields[i:]...)
+				break
+			}
+		}
+		if len(fields) == 2 {
+			fields = append(fields, ImportStatic)
+		}
+	}
+	if len(fields) < 3 || len(fields) > 5 {
+		return Import{}, fmt.Errorf("malformed import %q", entry)
+	}
+
+	line, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return Import{}, fmt.Errorf("malformed import %q", entry)
+	}
+
+	imp := Import{
+		Path:    r.unescape(fields[0]),
+		Symbols: []string{},
+		Kind:    r.unescape(fields[2]),
+		Line:    line,
+	}
+	if len(fields) >= 4 {
+		imp.Symbols = r.unescapeList(fields[3], ";")
+	}
+	if len(fields) == 5 {
+		imp.Uses = r.unescapeList(fields[4], ";")
+	}
+	for _, symbol := range imp.Symbols {
+		if strings.HasPrefix(symbol, "default ") {
+			imp.IsDefault = true
+		}
+	}
+	return imp, nil
+}
+
+// readExport decodes name:type:line followed by the optional default and
+// visibility flags; exports without a visibility flag are public
+func (r *toonReader) readExport(entry string) (Export, error) {
+	fields := strings.Split(entry, ":")
+	if len(fields) < 3 {
+		return Export{}, fmt.Errorf("malformed export %q", entry)
+	}
+
+	line, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return Export{}, fmt.Errorf("malformed export %q", entry)
+	}
+
+	exp := Export{
+		Name:       r.unescape(fields[0]),
+		Type:       r.unescape(fields[1]),
+		Visibility: VisibilityPublic,
+		Line:       line,
+	}
+	for _, flag := range fields[3:] {
+		switch flag = r.unescape(flag); flag {
+		case "default":
+			exp.IsDefault = true
+		case VisibilityPackage, VisibilityPrivate:
+			exp.Visibility = flag
+		default:
+			return Export{}, fmt.Errorf("malformed export %q", entry)
+		}
+	}
+	return exp, nil
+}
+
+// readUnused decodes path:name:type:line
+func (r *toonReader) readUnused(value string) error {
+	fields := strings.Split(value, ":")
+	if len(fields) != 4 {
+		return fmt.Errorf("malformed unused export %q", value)
+	}
+	line, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return fmt.Errorf("malformed unused export %q", value)
+	}
+	r.graph.UnusedExports = append(r.graph.UnusedExports, UnusedExport{
+		Path: r.unescape(fields[0]),
+		Name: r.unescape(fields[1]),
+		Type: r.unescape(fields[2]),
+		Line: line,
+	})
+	return nil
+}
+
+func (r *toonReader) unescape(value string) string {
+	if r.version == 1 {
+		return value
+	}
+	return toonUnescaper.Replace(value)
+}
+
+// unescapeList splits a sep-separated value and unescapes each entry
+func (r *toonReader) unescapeList(value, sep string) []string {
+	values := splitNonEmpty(value, sep)
+	for i, v := range values {
+		values[i] = r.unescape(v)
+	}
+	return values
+}
+
+// splitNonEmpty splits value on sep, returning an empty slice for ""
+func splitNonEmpty(value, sep string) []string {
+	if value == "" {
+		return []string{}
+	}
+	return strings.Split(value, sep)
+}
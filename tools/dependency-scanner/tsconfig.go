@@ -0,0 +1,285 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// tsConfig holds the module resolution settings of a tsconfig.json or
+// jsconfig.json after its extends chain has been applied
+type tsConfig struct {
+	path      string              // config file the settings were loaded from
+	baseURL   string              // compilerOptions.baseUrl, relative to its config
+	paths     map[string][]string // compilerOptions.paths
+	pathsBase string              // directory of the config that declared paths
+}
+
+// rawTSConfig is the subset of tsconfig.json we read
+type rawTSConfig struct {
+	Extends         json.RawMessage `json:"extends"`
+	CompilerOptions struct {
+		BaseURL *string             `json:"baseUrl"`
+		Paths   map[string][]string `json:"paths"`
+	} `json:"compilerOptions"`
+}
+
+// tsConfigResolver finds and caches the config that applies to each directory
+type tsConfigResolver struct {
+	byDir  map[string]*tsConfig // nearest config per directory (nil if none)
+	byPath map[string]*tsConfig // loaded config files
+}
+
+func newTSConfigResolver() *tsConfigResolver {
+	return &tsConfigResolver{
+		byDir:  make(map[string]*tsConfig),
+		byPath: make(map[string]*tsConfig),
+	}
+}
+
+// forDir returns the nearest tsconfig.json/jsconfig.json at or above dir
+func (r *tsConfigResolver) forDir(dir string) *tsConfig {
+	if cfg, cached := r.byDir[dir]; cached {
+		return cfg
+	}
+
+	var cfg *tsConfig
+	for _, name := range []string{"tsconfig.json", "jsconfig.json"} {
+		candidate := filepath.Join(dir, name)
+		if _, err := os.Stat(candidate); err == nil {
+			cfg = r.load(candidate, map[string]bool{})
+			break
+		}
+	}
+
+	if cfg == nil {
+		if parent := filepath.Dir(dir); parent != dir {
+			cfg = r.forDir(parent)
+		}
+	}
+
+	r.byDir[dir] = cfg
+	return cfg
+}
+
+// load reads a config file and merges it over the configs it extends
+func (r *tsConfigResolver) load(configPath string, visiting map[string]bool) *tsConfig {
+	if cfg, cached := r.byPath[configPath]; cached {
+		return cfg
+	}
+	if visiting[configPath] {
+		return nil // extends cycle
+	}
+	visiting[configPath] = true
+
+	content, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil
+	}
+
+	var raw rawTSConfig
+	if err := json.Unmarshal(stripJSONComments(content), &raw); err != nil {
+		return nil
+	}
+
+	configDir := filepath.Dir(configPath)
+	cfg := &tsConfig{path: configPath}
+
+	// extends may be a single string or (TS 5.0+) an array applied in order
+	var parents []string
+	var single string
+	if err := json.Unmarshal(raw.Extends, &single); err == nil && single != "" {
+		parents = []string{single}
+	} else {
+		_ = json.Unmarshal(raw.Extends, &parents)
+	}
+	for _, ext := range parents {
+		parentPath := resolveTSConfigExtends(configDir, ext)
+		if parentPath == "" {
+			continue
+		}
+		if parent := r.load(parentPath, visiting); parent != nil {
+			if parent.baseURL != "" {
+				cfg.baseURL = parent.baseURL
+			}
+			if parent.paths != nil {
+				cfg.paths = parent.paths
+				cfg.pathsBase = parent.pathsBase
+			}
+		}
+	}
+
+	if raw.CompilerOptions.BaseURL != nil {
+		cfg.baseURL = filepath.Join(configDir, *raw.CompilerOptions.BaseURL)
+	}
+	if raw.CompilerOptions.Paths != nil {
+		cfg.paths = raw.CompilerOptions.Paths
+		cfg.pathsBase = configDir
+	}
+
+	r.byPath[configPath] = cfg
+	return cfg
+}
+
+// resolveTSConfigExtends locates the file named by an extends entry, which
+// is either a relative path or a package in node_modules
+func resolveTSConfigExtends(configDir, ext string) string {
+	var candidates []string
+	if strings.HasPrefix(ext, ".") || filepath.IsAbs(ext) {
+		base := ext
+		if !filepath.IsAbs(base) {
+			base = filepath.Join(configDir, ext)
+		}
+		candidates = append(candidates, base, base+".json", filepath.Join(base, "tsconfig.json"))
+	} else {
+		for dir := configDir; ; dir = filepath.Dir(dir) {
+			base := filepath.Join(dir, "node_modules", ext)
+			candidates = append(candidates, base, base+".json", filepath.Join(base, "tsconfig.json"))
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// candidates returns the locations an aliased import may refer to, most
+// specific paths pattern first, then baseUrl
+func (c *tsConfig) candidates(importPath string) []string {
+	var results []string
+
+	if len(c.paths) > 0 {
+		base := c.pathsBase
+		if c.baseURL != "" {
+			base = c.baseURL
+		}
+
+		// TypeScript prefers the pattern with the longest prefix before '*'
+		patterns := make([]string, 0, len(c.paths))
+		for pattern := range c.paths {
+			patterns = append(patterns, pattern)
+		}
+		sort.Slice(patterns, func(i, j int) bool {
+			pi := strings.Index(patterns[i]+"*", "*")
+			pj := strings.Index(patterns[j]+"*", "*")
+			if pi != pj {
+				return pi > pj
+			}
+			return patterns[i] < patterns[j]
+		})
+
+		for _, pattern := range patterns {
+			wildcard, ok := matchTSPathPattern(pattern, importPath)
+			if !ok {
+				continue
+			}
+			for _, target := range c.paths[pattern] {
+				results = append(results, filepath.Join(base, strings.Replace(target, "*", wildcard, 1)))
+			}
+			break
+		}
+	}
+
+	if c.baseURL != "" {
+		results = append(results, filepath.Join(c.baseURL, importPath))
+	}
+
+	return results
+}
+
+// matchTSPathPattern matches an import against a paths key such as "@/*"
+// and returns the text captured by the wildcard
+func matchTSPathPattern(pattern, importPath string) (string, bool) {
+	star := strings.Index(pattern, "*")
+	if star < 0 {
+		return "", pattern == importPath
+	}
+	prefix, suffix := pattern[:star], pattern[star+1:]
+	if len(importPath) < len(prefix)+len(suffix) ||
+		!strings.HasPrefix(importPath, prefix) || !strings.HasSuffix(importPath, suffix) {
+		return "", false
+	}
+	return importPath[len(prefix) : len(importPath)-len(suffix)], true
+}
+
+// stripJSONComments turns JSONC (as used by tsconfig.json) into plain JSON
+// by removing comments and then trailing commas outside of strings
+func stripJSONComments(data []byte) []byte {
+	return stripTrailingCommas(scanJSONC(data, func(data []byte, i int) (int, bool) {
+		if data[i] != '/' || i+1 >= len(data) {
+			return i, false
+		}
+		switch data[i+1] {
+		case '/':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+			return i - 1, true
+		case '*':
+			i += 2
+			for i+1 < len(data) && !(data[i] == '*' && data[i+1] == '/') {
+				i++
+			}
+			return i + 1, true
+		}
+		return i, false
+	}))
+}
+
+// stripTrailingCommas removes commas directly followed by '}' or ']'
+func stripTrailingCommas(data []byte) []byte {
+	return scanJSONC(data, func(data []byte, i int) (int, bool) {
+		if data[i] != ',' {
+			return i, false
+		}
+		j := i + 1
+		for j < len(data) && strings.ContainsRune(" \t\r\n", rune(data[j])) {
+			j++
+		}
+		return i, j < len(data) && (data[j] == '}' || data[j] == ']')
+	})
+}
+
+// scanJSONC copies data, letting skip consume bytes outside string literals.
+// skip returns the index of the last consumed byte and whether it consumed any.
+func scanJSONC(data []byte, skip func(data []byte, i int) (int, bool)) []byte {
+	out := make([]byte, 0, len(data))
+	inString := false
+
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+
+		if inString {
+			out = append(out, c)
+			if c == '\\' && i+1 < len(data) {
+				i++
+				out = append(out, data[i])
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+
+		if c == '"' {
+			inString = true
+			out = append(out, c)
+			continue
+		}
+
+		if last, skipped := skip(data, i); skipped {
+			i = last
+			continue
+		}
+		out = append(out, c)
+	}
+
+	return out
+}
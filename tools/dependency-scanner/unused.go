@@ -0,0 +1,151 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// UnusedExport is a public symbol that no other file imports or references
+type UnusedExport struct {
+	Path string
+	Name string
+	Type string
+	Line int
+}
+
+// DetectUnusedExports reports the public symbols of each file that no
+// other file in the graph imports by name or accesses through a namespace
+// import. Go methods are skipped since calls on values can't be traced to
+// their type. Entry points are skipped because they are used from outside
+// the project, and so are dead files, whose exports are all unused.
+// Imports whose use can't be narrowed down, such as `export *`, a dynamic
+// import() or a namespace passed around as a value, count as using every
+// symbol of the imported file.
+func DetectUnusedExports(graph *DependencyGraph) []UnusedExport {
+	used := make(map[string]map[string]bool)
+	usesAll := make(map[string]bool)
+	use := func(path, name string) {
+		if used[path] == nil {
+			used[path] = make(map[string]bool)
+		}
+		used[path][name] = true
+	}
+
+	for importer, node := range graph.Files {
+		for _, imp := range node.Imports {
+			target, ok := graph.Files[imp.Path]
+			if !ok || imp.Path == importer || imp.Kind == ImportSideEffect {
+				continue
+			}
+			names, all := usedSymbols(node.Language, target, imp)
+			if all {
+				usesAll[imp.Path] = true
+			}
+			for _, name := range names {
+				use(imp.Path, name)
+			}
+		}
+	}
+
+	dead := make(map[string]bool, len(graph.DeadCode))
+	for _, path := range graph.DeadCode {
+		dead[path] = true
+	}
+
+	unused := []UnusedExport{}
+	for _, path := range graph.SortedPaths() {
+		node := graph.Files[path]
+		if usesAll[path] || dead[path] || node.EntryPoint != "" {
+			continue
+		}
+		for _, exp := range node.Exports {
+			if !exp.IsPublic() || exp.Type == ExportMethod {
+				continue
+			}
+			if used[path][exp.Name] || (exp.IsDefault && used[path]["default"]) {
+				continue
+			}
+			unused = append(unused, UnusedExport{Path: path, Name: exp.Name, Type: exp.Type, Line: exp.Line})
+		}
+	}
+	return unused
+}
+
+// usedSymbols returns the names one resolved import uses from its target,
+// or all=true if it may use any of them
+func usedSymbols(lang string, target *FileNode, imp Import) (names []string, all bool) {
+	for _, use := range imp.Uses {
+		if use == "*" {
+			return nil, true
+		}
+	}
+
+	switch lang {
+	case "go":
+		if len(imp.Symbols) == 1 && imp.Symbols[0] == "." {
+			return nil, true
+		}
+		return imp.Uses, len(imp.Uses) == 0
+
+	case "python":
+		if len(imp.Symbols) == 0 {
+			return imp.Uses, len(imp.Uses) == 0
+		}
+		module := pythonModuleName(target.Path)
+		for _, symbol := range imp.Symbols {
+			name := strings.Fields(symbol)[0]
+			switch {
+			case symbol == "*":
+				return nil, true
+			case name == "*":
+				// import m as alias
+				names = append(names, imp.Uses...)
+			case name == module:
+				// from pkg import submodule: its members are accessed
+				// as submodule.member
+				members := 0
+				for _, use := range imp.Uses {
+					if member, ok := strings.CutPrefix(use, name+"."); ok {
+						names = append(names, member)
+						members++
+					}
+				}
+				if members == 0 {
+					return nil, true
+				}
+			default:
+				names = append(names, name)
+			}
+		}
+		return names, false
+
+	default:
+		if len(imp.Symbols) == 0 {
+			// Bare require() and import() calls
+			return nil, true
+		}
+		for _, symbol := range imp.Symbols {
+			name := strings.Fields(symbol)[0]
+			switch {
+			case symbol == "*":
+				return nil, true
+			case name == "*":
+				continue
+			default:
+				names = append(names, name)
+			}
+		}
+		return append(names, imp.Uses...), false
+	}
+}
+
+// pythonModuleName returns the last component of a Python module's name:
+// mod for mod.py and pkg for pkg/__init__.py
+func pythonModuleName(path string) string {
+	base := filepath.Base(path)
+	stem := strings.TrimSuffix(base, filepath.Ext(base))
+	if stem == "__init__" {
+		return filepath.Base(filepath.Dir(path))
+	}
+	return stem
+}
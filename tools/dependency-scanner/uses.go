@@ -0,0 +1,180 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+)
+
+// useBinding is a local name through which a file reaches an import
+type useBinding struct {
+	imp    int    // Index of the import
+	prefix string // Prepended to recorded members: "name." for `from m import name`
+}
+
+// goMajorVersion matches the /v2 suffix of a Go module path
+var goMajorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// importBindings maps the names that stand for a whole imported module to
+// their imports: Go package names, JS `* as ns` and require() bindings,
+// and Python `import a.b [as m]`. Python `from m import name` binds names
+// that may be submodules, recorded with a prefix.
+func importBindings(imports []Import, lang string) map[string][]useBinding {
+	bindings := make(map[string][]useBinding)
+	for i, imp := range imports {
+		switch lang {
+		case "go":
+			name := goPackageName(imp.Path)
+			if len(imp.Symbols) == 1 {
+				name = imp.Symbols[0]
+			}
+			if name != "_" && name != "." {
+				bindings[name] = append(bindings[name], useBinding{imp: i})
+			}
+		case "python":
+			if len(imp.Symbols) == 0 {
+				bindings[imp.Path] = append(bindings[imp.Path], useBinding{imp: i})
+			}
+			for _, symbol := range imp.Symbols {
+				fields := strings.Fields(symbol)
+				switch {
+				case fields[0] == "*" && len(fields) == 3:
+					bindings[fields[2]] = append(bindings[fields[2]], useBinding{imp: i})
+				case fields[0] != "*":
+					local := fields[len(fields)-1]
+					bindings[local] = append(bindings[local], useBinding{imp: i, prefix: fields[0] + "."})
+				}
+			}
+		default:
+			for _, symbol := range imp.Symbols {
+				if ns, ok := strings.CutPrefix(symbol, "* as "); ok {
+					bindings[ns] = append(bindings[ns], useBinding{imp: i})
+				}
+			}
+		}
+	}
+	return bindings
+}
+
+// goPackageName guesses the name a Go import path binds when imported
+// without an alias: the last element, skipping a major version suffix and
+// a go- prefix or -go suffix
+func goPackageName(importPath string) string {
+	parts := strings.Split(importPath, "/")
+	name := parts[len(parts)-1]
+	if len(parts) > 1 && goMajorVersion.MatchString(name) {
+		name = parts[len(parts)-2]
+	}
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(strings.TrimSuffix(name, "-go"), ".go")
+	return name
+}
+
+// memberAccess returns the qualifier and member of a qualified reference
+// such as pkg.Func, ns.name, ns.Type or module.attr
+func memberAccess(n *sitter.Node) (*sitter.Node, *sitter.Node) {
+	switch n.Type() {
+	case "selector_expression":
+		return n.ChildByFieldName("operand"), n.ChildByFieldName("field")
+	case "qualified_type":
+		return n.ChildByFieldName("package"), n.ChildByFieldName("name")
+	case "member_expression":
+		return n.ChildByFieldName("object"), n.ChildByFieldName("property")
+	case "nested_type_identifier":
+		return n.ChildByFieldName("module"), n.ChildByFieldName("name")
+	case "attribute":
+		return n.ChildByFieldName("object"), n.ChildByFieldName("attribute")
+	}
+	return nil, nil
+}
+
+// collectUses fills Import.Uses with the members a file accesses through
+// its module bindings. A namespace that is also used on its own, for
+// example passed to a function, is recorded as "*", as is one whose uses
+// cannot be found, since its members may then be used in any way.
+func (p *Parser) collectUses(root *sitter.Node, content []byte, lang string, imports []Import) {
+	bindings := importBindings(imports, lang)
+	if len(bindings) == 0 {
+		return
+	}
+
+	uses := make([]map[string]bool, len(imports))
+	record := func(targets []useBinding, member string, whole bool) {
+		for _, b := range targets {
+			if whole && b.prefix != "" {
+				// Imported functions and classes are used on their own
+				continue
+			}
+			if uses[b.imp] == nil {
+				uses[b.imp] = make(map[string]bool)
+			}
+			uses[b.imp][b.prefix+member] = true
+		}
+	}
+
+	var walk func(n *sitter.Node)
+	walk = func(n *sitter.Node) {
+		switch n.Type() {
+		case "import_declaration", "import_statement", "import_from_statement":
+			return
+		case "identifier":
+			// Go package names never appear on their own
+			if targets, ok := bindings[nodeText(n, content)]; ok && lang != "go" && !isBindingDeclaration(n) {
+				record(targets, "*", true)
+			}
+			return
+		}
+
+		object, member := memberAccess(n)
+		if object != nil && member != nil {
+			if targets, ok := bindings[nodeText(object, content)]; ok {
+				record(targets, nodeText(member, content), false)
+				for i := 0; i < int(n.NamedChildCount()); i++ {
+					if child := n.NamedChild(i); child.StartByte() != object.StartByte() || child.EndByte() != object.EndByte() {
+						walk(child)
+					}
+				}
+				return
+			}
+		}
+
+		for i := 0; i < int(n.NamedChildCount()); i++ {
+			walk(n.NamedChild(i))
+		}
+	}
+	walk(root)
+
+	// Found no uses: the binding was guessed wrong or is used dynamically
+	for _, targets := range bindings {
+		for _, b := range targets {
+			if b.prefix == "" && len(uses[b.imp]) == 0 {
+				record([]useBinding{b}, "*", false)
+			}
+		}
+	}
+
+	for i, members := range uses {
+		if len(members) == 0 {
+			continue
+		}
+		list := make([]string, 0, len(members))
+		for member := range members {
+			list = append(list, member)
+		}
+		sort.Strings(list)
+		imports[i].Uses = list
+	}
+}
+
+// isBindingDeclaration reports whether an identifier is the variable a
+// require() call is assigned to, as in `const m = require("./m")`
+func isBindingDeclaration(n *sitter.Node) bool {
+	parent := n.Parent()
+	if parent == nil || parent.Type() != "variable_declarator" {
+		return false
+	}
+	name := parent.ChildByFieldName("name")
+	return name != nil && name.StartByte() == n.StartByte() && name.EndByte() == n.EndByte()
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// WatchOptions configures Scanner.Watch
+type WatchOptions struct {
+	Debounce time.Duration // Quiet period before a batch of changes is applied
+	Interval time.Duration // Poll interval of the polling backend
+	Poll     bool          // Poll even if native file notifications are available
+}
+
+// fileWatcher reports paths of files and directories that may have changed.
+// Renames arrive as the old and the new path.
+type fileWatcher interface {
+	Events() <-chan string
+	Close() error
+}
+
+// Watch keeps the scanner resident after Scan and applies file changes to
+// the graph until stop is closed. Events are collected until none arrived
+// for the debounce window, then applied with Update, and onUpdate is called
+// with the new graph and the files that changed.
+func (s *Scanner) Watch(opts WatchOptions, stop <-chan struct{}, onUpdate func(graph *DependencyGraph, changed []string)) error {
+	var watcher fileWatcher
+	if !opts.Poll {
+		native, err := newNativeWatcher(s.rootPath, s.isExcludedDir)
+		if err != nil {
+			if s.verbose {
+				printf("Native file notifications unavailable (%v), polling every %s\n", err, opts.Interval)
+			}
+		} else {
+			watcher = native
+		}
+	}
+	if watcher == nil {
+		watcher = newPollWatcher(s, opts.Interval)
+	}
+	defer watcher.Close()
+
+	debounce := time.NewTimer(opts.Debounce)
+	debounce.Stop()
+
+	pending := make(map[string]bool)
+	for {
+		select {
+		case <-stop:
+			return nil
+
+		case path, ok := <-watcher.Events():
+			if !ok {
+				return nil
+			}
+			pending[path] = true
+			debounce.Reset(opts.Debounce)
+
+		case <-debounce.C:
+			paths := make([]string, 0, len(pending))
+			for path := range pending {
+				paths = append(paths, path)
+			}
+			pending = make(map[string]bool)
+
+			changed, err := s.Update(paths)
+			if err != nil {
+				return err
+			}
+			if changed != nil {
+				onUpdate(s.graph, changed)
+			}
+		}
+	}
+}
+
+// fileStamp identifies a version of a file for the polling backend
+type fileStamp struct {
+	size    int64
+	modTime int64
+}
+
+// pollWatcher detects changes by walking the tree every interval and
+// comparing sizes and modification times of source, manifest and ignore
+// files
+type pollWatcher struct {
+	scanner  *Scanner
+	snapshot map[string]fileStamp
+	events   chan string
+	done     chan struct{}
+}
+
+// newPollWatcher starts polling from the state recorded by the last scan
+func newPollWatcher(s *Scanner, interval time.Duration) *pollWatcher {
+	w := &pollWatcher{
+		scanner:  s,
+		snapshot: make(map[string]fileStamp, len(s.entries)),
+		events:   make(chan string, 256),
+		done:     make(chan struct{}),
+	}
+	// Source files as of the scan, so that edits made since are noticed
+	for path, entry := range s.entries {
+		w.snapshot[path] = fileStamp{size: entry.Size, modTime: entry.ModTime}
+	}
+	w.walk(func(path string, stamp fileStamp) {
+		if !s.isSupportedFile(path) {
+			w.snapshot[path] = stamp
+		}
+	})
+
+	go w.run(interval)
+	return w
+}
+
+func (w *pollWatcher) Events() <-chan string {
+	return w.events
+}
+
+func (w *pollWatcher) Close() error {
+	close(w.done)
+	return nil
+}
+
+func (w *pollWatcher) run(interval time.Duration) {
+	defer close(w.events)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+		}
+
+		current := make(map[string]fileStamp, len(w.snapshot))
+		err := w.walk(func(path string, stamp fileStamp) {
+			current[path] = stamp
+		})
+		if err != nil {
+			// Keep the previous snapshot and retry on the next tick
+			continue
+		}
+
+		var changed []string
+		for path, stamp := range current {
+			if previous, ok := w.snapshot[path]; !ok || previous != stamp {
+				changed = append(changed, path)
+			}
+		}
+		for path := range w.snapshot {
+			if _, ok := current[path]; !ok {
+				changed = append(changed, path)
+			}
+		}
+		w.snapshot = current
+
+		for _, path := range changed {
+			select {
+			case w.events <- path:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// walk visits the files whose changes can affect the graph
+func (w *pollWatcher) walk(visit func(path string, stamp fileStamp)) error {
+	return w.scanner.walkTree(w.scanner.rootPath, false, func(path string, info os.FileInfo) {
+		name := filepath.Base(path)
+		if w.scanner.isSupportedFile(path) || isProjectManifest(name) || isIgnoreFile(name) {
+			visit(path, fileStamp{size: info.Size(), modTime: info.ModTime().UnixNano()})
+		}
+	})
+}
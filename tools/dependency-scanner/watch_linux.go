@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// inotifyMask selects the events that can change the set or content of
+// source files in a watched directory
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO |
+	syscall.IN_DELETE_SELF | syscall.IN_ONLYDIR
+
+// inotifyWatcher watches every non-excluded directory of the tree with
+// inotify, adding watches for directories created after startup
+type inotifyWatcher struct {
+	root    string
+	fd      int
+	file    *os.File
+	skipDir func(dir string) bool
+	watches map[int]string // watch descriptor -> directory, owned by run
+	events  chan string
+	done    chan struct{}
+}
+
+// newNativeWatcher returns an inotify watcher for root. It fails if
+// inotify is unavailable or the tree exceeds the user's watch limit.
+func newNativeWatcher(root string, skipDir func(dir string) bool) (fileWatcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &inotifyWatcher{
+		root: root,
+		fd:   fd,
+		// A non-blocking descriptor lets Close interrupt a pending Read
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		skipDir: skipDir,
+		watches: make(map[int]string),
+		events:  make(chan string, 256),
+		done:    make(chan struct{}),
+	}
+
+	if err := w.addTree(root); err != nil {
+		w.file.Close()
+		return nil, err
+	}
+
+	go w.run()
+	return w, nil
+}
+
+func (w *inotifyWatcher) Events() <-chan string {
+	return w.events
+}
+
+func (w *inotifyWatcher) Close() error {
+	close(w.done)
+	return w.file.Close()
+}
+
+// addTree watches dir and every non-excluded directory below it
+func (w *inotifyWatcher) addTree(dir string) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			// Vanished or unreadable entries are not worth failing for
+			return nil
+		}
+		if w.skipDir(path) {
+			return filepath.SkipDir
+		}
+
+		wd, err := syscall.InotifyAddWatch(w.fd, path, inotifyMask)
+		if err != nil {
+			if err == syscall.ENOSPC {
+				return err
+			}
+			return nil
+		}
+		w.watches[wd] = path
+		return nil
+	})
+}
+
+// removeTree drops the watches of a directory moved out of its parent; the
+// descriptors would otherwise keep reporting events under the old path
+func (w *inotifyWatcher) removeTree(dir string) {
+	prefix := dir + string(filepath.Separator)
+	for wd, path := range w.watches {
+		if path == dir || strings.HasPrefix(path, prefix) {
+			syscall.InotifyRmWatch(w.fd, uint32(wd))
+			delete(w.watches, wd)
+		}
+	}
+}
+
+func (w *inotifyWatcher) run() {
+	defer close(w.events)
+
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := w.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			// struct inotify_event { int wd; uint32 mask, cookie, len; char name[]; }
+			wd := int(int32(binary.NativeEndian.Uint32(buf[offset:])))
+			mask := binary.NativeEndian.Uint32(buf[offset+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+			offset += syscall.SizeofInotifyEvent
+
+			name := strings.TrimRight(string(buf[offset:offset+nameLen]), "\x00")
+			offset += nameLen
+
+			path, ok := w.handle(wd, mask, name)
+			if !ok {
+				continue
+			}
+			select {
+			case w.events <- path:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// handle updates the watch set for one event and returns the path it
+// concerns, if any
+func (w *inotifyWatcher) handle(wd int, mask uint32, name string) (string, bool) {
+	if mask&syscall.IN_Q_OVERFLOW != 0 {
+		// Events were lost: have the whole tree re-checked
+		return w.root, true
+	}
+
+	dir, ok := w.watches[wd]
+	if !ok {
+		return "", false
+	}
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(w.watches, wd)
+		return "", false
+	}
+
+	path := dir
+	if name != "" {
+		path = filepath.Join(dir, name)
+	}
+
+	if mask&syscall.IN_ISDIR != 0 && name != "" {
+		switch {
+		case mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+			// Files created before the watch was added are found by Update
+			// walking the reported directory
+			w.addTree(path)
+		case mask&syscall.IN_MOVED_FROM != 0:
+			w.removeTree(path)
+		}
+	}
+
+	return path, true
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// newNativeWatcher is only implemented on Linux; other platforms poll
+func newNativeWatcher(root string, skipDir func(dir string) bool) (fileWatcher, error) {
+	return nil, errors.New("not supported on this platform")
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// workspacePackage is a local package of an npm/pnpm/yarn workspace
+type workspacePackage struct {
+	Name    string
+	Dir     string
+	Main    string
+	Module  string
+	Exports json.RawMessage
+}
+
+// packageJSON is the subset of package.json we read
+type packageJSON struct {
+	Name       string          `json:"name"`
+	Main       string          `json:"main"`
+	Module     string          `json:"module"`
+	Exports    json.RawMessage `json:"exports"`
+	Bin        json.RawMessage `json:"bin"`
+	Workspaces json.RawMessage `json:"workspaces"`
+}
+
+// exportConditions is the order in which conditional exports are tried.
+// Source-oriented conditions come first so edges point at files we parse
+// rather than build output.
+var exportConditions = []string{"source", "development", "import", "module", "require", "node", "default", "types"}
+
+// workspaceSet indexes workspace packages by name and directory
+type workspaceSet struct {
+	byName map[string]*workspacePackage
+	dirs   []*workspacePackage // sorted by descending directory length
+}
+
+// discoverWorkspaces finds the packages listed in package.json "workspaces"
+// and pnpm-workspace.yaml under rootPath. Returns nil when the project
+// isn't a workspace.
+func discoverWorkspaces(rootPath string, skipDir func(dir string) bool) *workspaceSet {
+	var patterns []string
+
+	if content, err := os.ReadFile(filepath.Join(rootPath, "package.json")); err == nil {
+		var pkg packageJSON
+		if json.Unmarshal(content, &pkg) == nil && len(pkg.Workspaces) > 0 {
+			// "workspaces": [...] or "workspaces": {"packages": [...]}
+			var list []string
+			if json.Unmarshal(pkg.Workspaces, &list) != nil {
+				var obj struct {
+					Packages []string `json:"packages"`
+				}
+				_ = json.Unmarshal(pkg.Workspaces, &obj)
+				list = obj.Packages
+			}
+			patterns = append(patterns, list...)
+		}
+	}
+
+	if content, err := os.ReadFile(filepath.Join(rootPath, "pnpm-workspace.yaml")); err == nil {
+		patterns = append(patterns, parsePnpmWorkspace(string(content))...)
+	}
+
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	var include, exclude []string
+	for _, pattern := range patterns {
+		pattern = strings.TrimPrefix(filepath.ToSlash(pattern), "./")
+		if strings.HasPrefix(pattern, "!") {
+			exclude = append(exclude, strings.TrimPrefix(pattern[1:], "./"))
+		} else {
+			include = append(include, pattern)
+		}
+	}
+
+	set := &workspaceSet{byName: make(map[string]*workspacePackage)}
+
+	filepath.Walk(rootPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || !info.IsDir() {
+			return nil
+		}
+		if path != rootPath && skipDir(path) {
+			return filepath.SkipDir
+		}
+
+		rel, err := filepath.Rel(rootPath, path)
+		if err != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+
+		if !matchesAny(include, rel) || matchesAny(exclude, rel) {
+			return nil
+		}
+
+		content, err := os.ReadFile(filepath.Join(path, "package.json"))
+		if err != nil {
+			return nil
+		}
+		var pkg packageJSON
+		if json.Unmarshal(content, &pkg) != nil || pkg.Name == "" {
+			return nil
+		}
+
+		wp := &workspacePackage{
+			Name:    pkg.Name,
+			Dir:     path,
+			Main:    pkg.Main,
+			Module:  pkg.Module,
+			Exports: pkg.Exports,
+		}
+		set.byName[pkg.Name] = wp
+		set.dirs = append(set.dirs, wp)
+		return nil
+	})
+
+	if len(set.byName) == 0 {
+		return nil
+	}
+
+	sort.Slice(set.dirs, func(i, j int) bool {
+		return len(set.dirs[i].Dir) > len(set.dirs[j].Dir)
+	})
+
+	return set
+}
+
+// parsePnpmWorkspace extracts the packages list from pnpm-workspace.yaml,
+// accepting both block ("- 'packages/*'") and flow ("['a/*']") sequences
+func parsePnpmWorkspace(content string) []string {
+	var patterns []string
+	inPackages := false
+
+	unquote := func(s string) string {
+		s = strings.TrimSpace(s)
+		if i := strings.Index(s, " #"); i >= 0 {
+			s = strings.TrimSpace(s[:i])
+		}
+		return strings.Trim(s, `'"`)
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "packages:") {
+			inPackages = true
+			rest := strings.TrimSpace(strings.TrimPrefix(line, "packages:"))
+			if strings.HasPrefix(rest, "[") {
+				for _, item := range strings.Split(strings.Trim(rest, "[]"), ",") {
+					if item = unquote(item); item != "" {
+						patterns = append(patterns, item)
+					}
+				}
+				inPackages = false
+			}
+			continue
+		}
+
+		// Any other top-level key ends the packages list
+		if line[0] != ' ' && line[0] != '\t' && line[0] != '-' {
+			inPackages = false
+			continue
+		}
+
+		if inPackages && strings.HasPrefix(trimmed, "-") {
+			if item := unquote(strings.TrimPrefix(trimmed, "-")); item != "" {
+				patterns = append(patterns, item)
+			}
+		}
+	}
+
+	return patterns
+}
+
+func matchesAny(patterns []string, rel string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// packageFor returns the workspace package containing a file, if any
+func (w *workspaceSet) packageFor(filePath string) *workspacePackage {
+	for _, wp := range w.dirs {
+		if filePath == wp.Dir || strings.HasPrefix(filePath, wp.Dir+string(filepath.Separator)) {
+			return wp
+		}
+	}
+	return nil
+}
+
+// candidates maps a bare specifier such as "@acme/ui" or "@acme/ui/button"
+// to the files it may refer to inside a local workspace package
+func (w *workspaceSet) candidates(specifier string) []string {
+	name, subpath := splitPackageSpecifier(specifier)
+	wp, ok := w.byName[name]
+	if !ok {
+		return nil
+	}
+
+	var targets []string
+	if len(wp.Exports) > 0 && string(wp.Exports) != "null" {
+		targets = resolvePackageExports(wp.Exports, "."+subpath)
+	}
+
+	if subpath == "" {
+		for _, entry := range []string{wp.Module, wp.Main} {
+			if entry != "" {
+				targets = append(targets, entry)
+			}
+		}
+		targets = append(targets, "index", "src/index")
+	} else {
+		targets = append(targets, strings.TrimPrefix(subpath, "/"), "src"+subpath)
+	}
+
+	candidates := make([]string, 0, len(targets))
+	for _, target := range targets {
+		candidates = append(candidates, filepath.Join(wp.Dir, filepath.FromSlash(target)))
+	}
+	return candidates
+}
+
+// splitPackageSpecifier splits "@scope/pkg/sub/path" into "@scope/pkg" and "/sub/path"
+func splitPackageSpecifier(specifier string) (string, string) {
+	parts := strings.SplitN(specifier, "/", 3)
+	if strings.HasPrefix(specifier, "@") && len(parts) >= 2 {
+		name := parts[0] + "/" + parts[1]
+		return name, strings.TrimPrefix(specifier, name)
+	}
+	name := parts[0]
+	return name, strings.TrimPrefix(specifier, name)
+}
+
+// resolvePackageExports returns the targets of a package.json "exports"
+// field for a subpath such as "." or "./button", in condition order
+func resolvePackageExports(exports json.RawMessage, subpath string) []string {
+	// Sugar: "exports": "./index.js" or {"import": ..., "require": ...}
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(exports, &obj) != nil || !hasSubpathKeys(obj) {
+		if subpath != "." {
+			return nil
+		}
+		return exportTargets(exports)
+	}
+
+	if value, ok := obj[subpath]; ok {
+		return exportTargets(value)
+	}
+
+	// Subpath patterns: "./*": "./src/*.ts"
+	keys := make([]string, 0, len(obj))
+	for key := range obj {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return len(keys[i]) > len(keys[j]) })
+
+	for _, key := range keys {
+		wildcard, ok := matchTSPathPattern(key, subpath)
+		if !ok || !strings.Contains(key, "*") {
+			continue
+		}
+		var targets []string
+		for _, target := range exportTargets(obj[key]) {
+			targets = append(targets, strings.ReplaceAll(target, "*", wildcard))
+		}
+		return targets
+	}
+
+	return nil
+}
+
+func hasSubpathKeys(obj map[string]json.RawMessage) bool {
+	for key := range obj {
+		if strings.HasPrefix(key, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+// exportTargets flattens a conditional export value into target paths
+func exportTargets(value json.RawMessage) []string {
+	var target string
+	if json.Unmarshal(value, &target) == nil {
+		return []string{target}
+	}
+
+	var fallbacks []json.RawMessage
+	if json.Unmarshal(value, &fallbacks) == nil {
+		var targets []string
+		for _, fallback := range fallbacks {
+			targets = append(targets, exportTargets(fallback)...)
+		}
+		return targets
+	}
+
+	var conditions map[string]json.RawMessage
+	if json.Unmarshal(value, &conditions) != nil {
+		return nil
+	}
+	var targets []string
+	for _, condition := range exportConditions {
+		if nested, ok := conditions[condition]; ok {
+			targets = append(targets, exportTargets(nested)...)
+		}
+	}
+	return targets
+}
@@ -4,38 +4,21 @@
 set -eo pipefail
 
 SCRIPT_DIR="$(cd "$(dirname "${BASH_SOURCE[0]}")" && pwd)"
-LIB_DIR="$(cd "$SCRIPT_DIR/../../lib" && pwd)"
 
-source "$LIB_DIR/toon-parser.sh"
-
-GRAPH_FILE="${DEP_GRAPH_FILE:-.claude/dep-graph.toon}"
-
-if [ $# -ge 1 ]; then
-    GRAPH_FILE="$1"
+# Prefer the installed scanner, then a development build next to this tool
+SCANNER="${DEP_SCANNER_BIN:-$HOME/.claude/bin/dependency-scanner}"
+if [ ! -x "$SCANNER" ] && [ -x "$SCRIPT_DIR/../dependency-scanner/bin/dependency-scanner" ]; then
+    SCANNER="$SCRIPT_DIR/../dependency-scanner/bin/dependency-scanner"
 fi
-
-if [ ! -f "$GRAPH_FILE" ]; then
-    echo "Error: Graph file not found: $GRAPH_FILE"
-    echo "Run the dependency scanner first to generate the graph"
+if [ ! -x "$SCANNER" ]; then
+    echo "Error: dependency-scanner binary not found at ~/.claude/bin/dependency-scanner"
+    echo "Run installation to build it."
     exit 1
 fi
 
-DEADCODE=$(toon_get_deadcode "$GRAPH_FILE")
-
-if [ -z "$DEADCODE" ]; then
-    echo "No dead code found - all files are imported somewhere"
-    exit 0
+GRAPH_ARGS=()
+if [ $# -ge 1 ]; then
+    GRAPH_ARGS=(-graph "$1")
 fi
 
-DEAD_COUNT=$(echo "$DEADCODE" | wc -l | tr -d ' ')
-
-echo "Found $DEAD_COUNT potentially unused file(s):"
-echo ""
-
-echo "$DEADCODE" | while IFS= read -r dead_file; do
-    echo "  - $dead_file"
-done
-echo ""
-
-echo "Note: These files are not imported by any other file in the project."
-echo "They may be entry points or genuinely unused code."
+exec "$SCANNER" query "${GRAPH_ARGS[@]}" dead